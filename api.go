@@ -0,0 +1,341 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+const (
+	apiV1Pattern = "/api/v1/"
+
+	// apiMaxPageSize is the most recipes returned in a page of the API
+	apiMaxPageSize = 100
+)
+
+// APIRecipe is a recipe with the URLs clients need to link to it
+// files are only linked by relative URLs, never by their paths on the server
+type APIRecipe struct {
+	// title of the recipe, used to look it up
+	ID string `json:"id"`
+	// relative URL to the recipe in the API
+	URL string `json:"url"`
+	// relative URL to the recipe page
+	PageURL string              `json:"page_url"`
+	Title   string              `json:"title"`
+	Info    map[string][]string `json:"info"`
+	// relative URL to download the docx
+	DocxURL string `json:"docx_url"`
+	// relative URLs to the scanned cards
+	ScanURLs    []string         `json:"scan_urls"`
+	Attachments []*APIAttachment `json:"attachments"`
+	Tags        []string         `json:"tags"`
+	Category    string           `json:"category"`
+	Author      string           `json:"author"`
+	Language    string           `json:"language"`
+	Created     time.Time        `json:"created"`
+	Modified    time.Time        `json:"modified"`
+}
+
+// APIAttachment is a document in a recipe's folder
+type APIAttachment struct {
+	Name string `json:"name"`
+	// relative URL to download the document
+	URL string `json:"url"`
+	// number of pages, 0 if they could not be counted
+	Pages int `json:"pages"`
+}
+
+// APISearchResult is a recipe matching a search
+type APISearchResult struct {
+	*APIRecipe
+	Score float64 `json:"score"`
+}
+
+// APIRecipeList is a page of recipes
+type APIRecipeList struct {
+	Recipes []*APIRecipe `json:"recipes"`
+	Total   uint64       `json:"total"`
+	Page    int          `json:"page"`
+	PerPage int          `json:"per_page"`
+}
+
+// APISearchResults is a page of search results
+type APISearchResults struct {
+	Query   string             `json:"query"`
+	Results []*APISearchResult `json:"results"`
+	Total   uint64             `json:"total"`
+	Page    int                `json:"page"`
+	PerPage int                `json:"per_page"`
+}
+
+// APIImages are the relative URLs of a recipe's images
+type APIImages struct {
+	// image embedded in the docx
+	StockImage string `json:"stock_image"`
+	// scanned recipe cards
+	Scans []string `json:"scans"`
+}
+
+// apiPage gets the one based page and the page size from the query parameters
+func apiPage(values url.Values, defaultSize int) (page int, size int, err error) {
+	page, size = 1, defaultSize
+	if rawPage := values.Get("page"); rawPage != "" {
+		page, err = strconv.Atoi(rawPage)
+		if err != nil || page < 1 {
+			return 0, 0, &apiError{http.StatusBadRequest, "page must be a positive integer"}
+		}
+	}
+
+	if rawSize := values.Get("per_page"); rawSize != "" {
+		size, err = strconv.Atoi(rawSize)
+		if err != nil || size < 1 || size > apiMaxPageSize {
+			return 0, 0, &apiError{http.StatusBadRequest, "per_page must be between 1 and " + strconv.Itoa(apiMaxPageSize)}
+		}
+	}
+
+	if page > maxPage(size) {
+		return 0, 0, &apiError{http.StatusBadRequest, "page must be at most " + strconv.Itoa(maxPage(size))}
+	}
+
+	return page, size, nil
+}
+
+// apiError is an error with the status code it is reported with
+type apiError struct {
+	Status  int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// apiRecipe converts a recipe.Recipe to an APIRecipe
+func (h *Handler) apiRecipe(rec *recipe.Recipe) *APIRecipe {
+	apiRec := &APIRecipe{
+		ID:          rec.Title,
+		URL:         apiV1Pattern + "recipes/" + url.PathEscape(rec.Title),
+		PageURL:     recipePattern + url.PathEscape(rec.Title),
+		Title:       rec.Title,
+		Info:        rec.Info,
+		ScanURLs:    h.scanURLs(rec),
+		Attachments: []*APIAttachment{},
+		Tags:        rec.Tags,
+		Category:    rec.Category,
+		Author:      rec.Author,
+		Language:    rec.Language,
+		Created:     rec.Created,
+		Modified:    rec.Modified,
+	}
+
+	if docxURL, err := h.pathToURL(rec.DocxPath, docxPattern); err == nil {
+		apiRec.DocxURL = docxURL
+	}
+
+	for _, attachment := range rec.Attachments {
+		urlPath, err := h.pathToURL(attachment.Path, attachmentPattern)
+		if err != nil {
+			continue
+		}
+
+		apiRec.Attachments = append(apiRec.Attachments, &APIAttachment{
+			Name:  filepath.Base(attachment.Path),
+			URL:   urlPath,
+			Pages: attachment.Pages,
+		})
+	}
+
+	return apiRec
+}
+
+// scanURLs are the relative URLs of the scans of a recipe
+func (h *Handler) scanURLs(rec *recipe.Recipe) []string {
+	urls := []string{}
+	for _, scanPath := range rec.ScanPaths {
+		urlPath, err := h.pathToURL(scanPath, imagePattern)
+		if err != nil {
+			continue
+		}
+
+		urls = append(urls, urlPath)
+	}
+
+	return urls
+}
+
+// APIV1 routes the versioned JSON API
+//
+//	GET /api/v1/recipes              page of recipes, filtered like /recipes/
+//	GET /api/v1/recipes/{id}         a single recipe
+//	GET /api/v1/recipes/{id}/images  image URLs of a recipe
+//	GET /api/v1/search?q=            page of search results
+func (h *Handler) APIV1(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), apiV1Pattern), "/")
+	for i, part := range parts {
+		unescaped, err := url.PathUnescape(part)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid path")
+			return
+		}
+
+		parts[i] = unescaped
+	}
+
+	var handler func(http.ResponseWriter, *http.Request) error
+	switch {
+	case len(parts) == 1 && parts[0] == "recipes":
+		handler = h.apiListRecipes
+	case len(parts) == 2 && parts[0] == "recipes":
+		handler = func(w http.ResponseWriter, r *http.Request) error {
+			return h.apiGetRecipe(w, parts[1])
+		}
+	case len(parts) == 3 && parts[0] == "recipes" && parts[2] == "images":
+		handler = func(w http.ResponseWriter, r *http.Request) error {
+			return h.apiRecipeImages(w, parts[1])
+		}
+	case len(parts) == 1 && parts[0] == "search":
+		handler = h.apiSearch
+	default:
+		writeJSONError(w, http.StatusNotFound, "no such endpoint")
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method "+r.Method+" is not allowed")
+		return
+	}
+
+	err := handler(w, r)
+	if err == nil {
+		return
+	}
+
+	if apiErr, ok := err.(*apiError); ok {
+		writeJSONError(w, apiErr.Status, apiErr.Message)
+		return
+	}
+
+	if syntaxErr, ok := err.(*SearchSyntaxError); ok {
+		writeJSONError(w, http.StatusBadRequest, syntaxErr.Error())
+		return
+	}
+
+	h.logger.WithError(err).WithField("url", r.URL.String()).Errorln("Failed to handle API request")
+	writeJSONError(w, http.StatusInternalServerError, "unable to handle the request")
+}
+
+// apiListRecipes handles a page of recipes
+func (h *Handler) apiListRecipes(w http.ResponseWriter, r *http.Request) error {
+	values := r.URL.Query()
+	page, perPage, err := apiPage(values, browsePageSize)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range browseFacets {
+		value := values.Get(f.Param)
+		if value != "" && f.filterQuery(value) == nil {
+			return &apiError{http.StatusBadRequest, "unknown " + f.Param + " \"" + value + "\""}
+		}
+	}
+
+	if sort := values.Get("sort"); sort != "" && browseSortOption(sort).Param != sort {
+		return &apiError{http.StatusBadRequest, "unknown sort \"" + sort + "\""}
+	}
+
+	request := newBrowseRequest(values, perPage)
+	request.Facets = nil
+	searchResults, err := h.idx.Search(request)
+	if err != nil {
+		return err
+	}
+
+	list := &APIRecipeList{
+		Recipes: []*APIRecipe{},
+		Total:   searchResults.Total,
+		Page:    page,
+		PerPage: perPage,
+	}
+
+	for _, hit := range searchResults.Hits {
+		if rec, exists := h.getRecipe(hit.ID); exists {
+			list.Recipes = append(list.Recipes, h.apiRecipe(rec))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, list)
+	return nil
+}
+
+// apiGetRecipe handles a single recipe
+func (h *Handler) apiGetRecipe(w http.ResponseWriter, id string) error {
+	rec, exists := h.getRecipe(id)
+	if !exists {
+		return &apiError{http.StatusNotFound, "no recipe \"" + id + "\""}
+	}
+
+	writeJSON(w, http.StatusOK, h.apiRecipe(rec))
+	return nil
+}
+
+// apiRecipeImages handles the image URLs of a recipe
+func (h *Handler) apiRecipeImages(w http.ResponseWriter, id string) error {
+	rec, exists := h.getRecipe(id)
+	if !exists {
+		return &apiError{http.StatusNotFound, "no recipe \"" + id + "\""}
+	}
+
+	images := &APIImages{
+		StockImage: stockImagePatten + url.PathEscape(rec.Title+".jpg"),
+		Scans:      h.scanURLs(rec),
+	}
+
+	writeJSON(w, http.StatusOK, images)
+	return nil
+}
+
+// apiSearch handles a page of search results
+func (h *Handler) apiSearch(w http.ResponseWriter, r *http.Request) error {
+	values := r.URL.Query()
+	searchText := strings.TrimSpace(values.Get("q"))
+	if searchText == "" {
+		return &apiError{http.StatusBadRequest, "q must not be empty"}
+	}
+
+	page, perPage, err := apiPage(values, searchPageSize)
+	if err != nil {
+		return err
+	}
+
+	searchResults, err := h.search(searchText, page, perPage, values.Get("lang"))
+	if err != nil {
+		return err
+	}
+
+	results := &APISearchResults{
+		Query:   searchText,
+		Results: []*APISearchResult{},
+		Total:   searchResults.Total,
+		Page:    page,
+		PerPage: perPage,
+	}
+
+	for _, hit := range searchResults.Hits {
+		if rec, exists := h.getRecipe(hit.ID); exists {
+			results.Results = append(results.Results, &APISearchResult{
+				APIRecipe: h.apiRecipe(rec),
+				Score:     hit.Score,
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, results)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAPIRecipe(t *testing.T) {
+	handler := newTestHandler(t)
+	mux := newTestMux(handler)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/recipes/"+url.PathEscape("Banana Bread"), nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", recorder.Code)
+	}
+
+	// paths on the server must not be given away
+	body := recorder.Body.String()
+	for _, leak := range []string{"_path", handler.recipePath + "/Baking", "banana bread.docx"} {
+		if strings.Contains(body, leak) {
+			t.Errorf("Expected no %s in the recipe, got %s", leak, body)
+		}
+	}
+
+	apiRec := &APIRecipe{}
+	err := json.Unmarshal(recorder.Body.Bytes(), apiRec)
+	if err != nil {
+		t.Fatal("Failed to decode recipe", err)
+	}
+
+	if apiRec.DocxURL != "/docx/Baking/Banana%20Bread/banana%20bread.docx" {
+		t.Errorf("Expected a relative docx URL, got %s", apiRec.DocxURL)
+	}
+
+	if strings.Join(apiRec.ScanURLs, ", ") != "/images/Baking/Banana%20Bread/front.jpg" {
+		t.Errorf("Expected relative scan URLs, got %v", apiRec.ScanURLs)
+	}
+
+	if len(apiRec.Attachments) != 1 {
+		t.Fatalf("Expected 1 attachment, got %d", len(apiRec.Attachments))
+	}
+
+	attachment := apiRec.Attachments[0]
+	if attachment.Name != "card.pdf" || attachment.URL != "/attachments/Baking/Banana%20Bread/card.pdf" || attachment.Pages != 2 {
+		t.Errorf("Expected card.pdf with a relative URL and 2 pages, got %+v", attachment)
+	}
+
+	// every linked file is served
+	for _, fileURL := range append(apiRec.ScanURLs, apiRec.DocxURL, attachment.URL) {
+		for _, pattern := range []string{imagePattern, docxPattern, attachmentPattern} {
+			if strings.HasPrefix(fileURL, pattern) && handler.urlToPath(fileURL, pattern) == "" {
+				t.Errorf("Expected %s to be in the recipes folder", fileURL)
+			}
+		}
+	}
+
+	// recipes without scans or attachments have empty lists
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/recipes/Pancakes", nil))
+	pancakes := map[string]interface{}{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &pancakes)
+	if err != nil {
+		t.Fatal("Failed to decode recipe", err)
+	}
+
+	for _, name := range []string{"scan_urls", "attachments"} {
+		if list, ok := pancakes[name].([]interface{}); !ok || len(list) != 0 {
+			t.Errorf("Expected an empty list of %s for Pancakes, got %v", name, pancakes[name])
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/query"
+)
+
+const (
+	// browsePageSize is how many recipe cards are on a page of /recipes/
+	browsePageSize = 24
+
+	// browseFacetSize is the most terms shown for a facet
+	browseFacetSize = 20
+)
+
+// numericRange is a named range of a numeric field, Min inclusive and Max exclusive
+type numericRange struct {
+	Name string
+	Min  *float64
+	Max  *float64
+}
+
+// facet is a filter on /recipes/ backed by a bleve facet
+type facet struct {
+	// query parameter used for filtering
+	Param string
+	// human readable name
+	Label string
+	// indexed field name
+	Field string
+	// ranges for numeric fields, terms are used if empty
+	Ranges []numericRange
+	// names shown for terms, the term itself is shown if missing
+	Labels map[string]string
+}
+
+// sortOption is a way to order /recipes/
+type sortOption struct {
+	Param string
+	Label string
+	Order []string
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+// browseFacets are the filters available on /recipes/ in display order
+var browseFacets = []facet{
+	{Param: "category", Label: "Category", Field: "category"},
+	{Param: "tag", Label: "Tags", Field: "tags"},
+	{Param: "author", Label: "Author", Field: "author"},
+	{Param: "lang", Label: "Language", Field: "language", Labels: languageNames},
+	{Param: "oven", Label: "Oven temperature", Field: "temperature", Ranges: []numericRange{
+		{Name: "Under 300°F", Min: float(1), Max: float(300)},
+		{Name: "300°F to 349°F", Min: float(300), Max: float(350)},
+		{Name: "350°F to 399°F", Min: float(350), Max: float(400)},
+		{Name: "400°F and up", Min: float(400)},
+	}},
+	{Param: "time", Label: "Time", Field: "total_minutes", Ranges: []numericRange{
+		{Name: "Under 30 minutes", Min: float(1), Max: float(30)},
+		{Name: "30 to 59 minutes", Min: float(30), Max: float(60)},
+		{Name: "1 to 2 hours", Min: float(60), Max: float(120)},
+		{Name: "Over 2 hours", Min: float(120)},
+	}},
+}
+
+// browseSortOptions are the orders available on /recipes/, the first is the default
+var browseSortOptions = []sortOption{
+	{Param: "title", Label: "Title", Order: []string{"sort_title"}},
+	{Param: "modified", Label: "Recently modified", Order: []string{"-modified", "sort_title"}},
+	{Param: "added", Label: "Recently added", Order: []string{"-created", "sort_title"}},
+}
+
+// filterQuery limits the facet to the value of its query parameter
+func (f facet) filterQuery(value string) query.Query {
+	if len(f.Ranges) == 0 {
+		termQuery := bleve.NewTermQuery(value)
+		termQuery.SetField(f.Field)
+		return termQuery
+	}
+
+	for _, numRange := range f.Ranges {
+		if numRange.Name == value {
+			rangeQuery := bleve.NewNumericRangeQuery(numRange.Min, numRange.Max)
+			rangeQuery.SetField(f.Field)
+			return rangeQuery
+		}
+	}
+
+	return nil
+}
+
+// facetRequest creates the bleve facet request for the facet
+func (f facet) facetRequest() *bleve.FacetRequest {
+	request := bleve.NewFacetRequest(f.Field, browseFacetSize)
+	for _, numRange := range f.Ranges {
+		request.AddNumericRange(numRange.Name, numRange.Min, numRange.Max)
+	}
+
+	return request
+}
+
+// browseSortOption finds the sort option for the parameter, or the default
+func browseSortOption(param string) sortOption {
+	for _, option := range browseSortOptions {
+		if option.Param == param {
+			return option
+		}
+	}
+
+	return browseSortOptions[0]
+}
+
+// browsePage gets the one based page number of pageSize results from the query parameters
+// it is at most maxPage, so the offset of the page can not overflow
+func browsePage(values url.Values, pageSize int) int {
+	page, err := strconv.Atoi(values.Get("page"))
+	if err != nil || page < 1 {
+		return 1
+	}
+
+	if page > maxPage(pageSize) {
+		return maxPage(pageSize)
+	}
+
+	return page
+}
+
+// maxPage is the last page of pageSize results that can be asked for
+// past it the offset of the first result would not fit in an int32
+func maxPage(pageSize int) int {
+	return math.MaxInt32 / pageSize
+}
+
+// browseURL creates a /recipes/ URL with key set to value, or removed if value is empty
+// changing anything other than the page goes back to the first page
+func browseURL(values url.Values, key, value string) string {
+	newValues := url.Values{}
+	for k, v := range values {
+		newValues[k] = v
+	}
+
+	if key != "page" {
+		newValues.Del("page")
+	}
+
+	if value == "" {
+		newValues.Del(key)
+	} else {
+		newValues.Set(key, value)
+	}
+
+	if len(newValues) == 0 {
+		return "/recipes/"
+	}
+
+	return "/recipes/?" + newValues.Encode()
+}
+
+// newBrowseRequest creates the search request for a page of pageSize recipes from the query parameters
+func newBrowseRequest(values url.Values, pageSize int) *bleve.SearchRequest {
+	filters := []query.Query{}
+	for _, f := range browseFacets {
+		value := values.Get(f.Param)
+		if value == "" {
+			continue
+		}
+
+		if filter := f.filterQuery(value); filter != nil {
+			filters = append(filters, filter)
+		}
+	}
+
+	var browseQuery query.Query = bleve.NewMatchAllQuery()
+	if len(filters) > 0 {
+		browseQuery = bleve.NewConjunctionQuery(filters...)
+	}
+
+	request := bleve.NewSearchRequestOptions(
+		browseQuery,
+		pageSize,
+		(browsePage(values, pageSize)-1)*pageSize,
+		false,
+	)
+
+	request.SortBy(browseSortOption(values.Get("sort")).Order)
+	for _, f := range browseFacets {
+		request.AddFacet(f.Param, f.facetRequest())
+	}
+
+	return request
+}
+
+// templateFacets converts bleve facet results into sidebar filters
+func templateFacets(values url.Values, results search.FacetResults) (facets []*TemplateFacet) {
+	for _, f := range browseFacets {
+		result, exists := results[f.Param]
+		if !exists {
+			continue
+		}
+
+		active := values.Get(f.Param)
+		tmplFacet := &TemplateFacet{
+			Label: f.Label,
+		}
+
+		if active != "" {
+			tmplFacet.ClearURL = browseURL(values, f.Param, "")
+		}
+
+		addTerm := func(name string, count int) {
+			if name == "" || count == 0 {
+				return
+			}
+
+			label := f.Labels[name]
+			if label == "" {
+				label = name
+			}
+
+			tmplFacet.Terms = append(tmplFacet.Terms, &TemplateFacetTerm{
+				Label:  label,
+				Count:  count,
+				URL:    browseURL(values, f.Param, name),
+				Active: name == active,
+			})
+		}
+
+		if len(f.Ranges) == 0 {
+			for _, term := range result.Terms {
+				addTerm(term.Term, term.Count)
+			}
+		} else {
+			// keep the ranges in their defined order
+			for _, numRange := range f.Ranges {
+				for _, rangeResult := range result.NumericRanges {
+					if rangeResult.Name == numRange.Name {
+						addTerm(rangeResult.Name, rangeResult.Count)
+					}
+				}
+			}
+		}
+
+		if len(tmplFacet.Terms) > 0 {
+			facets = append(facets, tmplFacet)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+// cardTitleRegexp finds the titles of the recipe cards on a page, in order
+var cardTitleRegexp = regexp.MustCompile(`class="recipeCardTitle"><h2>([^<]+?)(?: <small>|</h2>)`)
+
+// addTestRecipe adds and indexes a recipe in a handler from newTestHandler
+func addTestRecipe(t *testing.T, handler *Handler, rec *recipe.Recipe) {
+	handler.recipes[rec.Title] = rec
+	handler.recipeSlice = append(handler.recipeSlice, rec)
+	err := handler.idx.Index(rec.Title, newRecipeDocument(rec))
+	if err != nil {
+		t.Fatal("Failed to index", rec.Title, err)
+	}
+}
+
+// browse renders /recipes/ with the query and returns the page and the titles of its cards
+func browse(t *testing.T, handler *Handler, rawQuery string) (string, []string) {
+	recorder := httptest.NewRecorder()
+	handler.Recipes(recorder, httptest.NewRequest(http.MethodGet, "/recipes/?"+rawQuery, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for %s, got %d", rawQuery, recorder.Code)
+	}
+
+	body := recorder.Body.String()
+	titles := []string{}
+	for _, match := range cardTitleRegexp.FindAllStringSubmatch(body, -1) {
+		titles = append(titles, match[1])
+	}
+
+	return body, titles
+}
+
+func newBrowseTestHandler(t *testing.T) *Handler {
+	handler := newTestHandler(t)
+	templates, err := NewTemplate(nil)
+	if err != nil {
+		t.Fatal("Failed to parse templates", err)
+	}
+
+	handler.templates = templates
+	addTestRecipe(t, handler, &recipe.Recipe{
+		Title:    "apple Pie",
+		Category: "Baking",
+		Language: "en",
+		Tags:     []string{"Dessert"},
+		Created:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Modified: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Info: map[string][]string{
+			"ingredients": {"6 apples"},
+			"preparation": {"Bake for 50 minutes"},
+		},
+	})
+
+	addTestRecipe(t, handler, &recipe.Recipe{
+		Title:    "Zucchini Soup",
+		Category: "Soups",
+		Author:   "Ada",
+		Language: "en",
+		Created:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Modified: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		Info: map[string][]string{
+			"oven temperature": {"375 degrees"},
+			"ingredients":      {"2 zucchini"},
+			"preparation":      {"Roast for 15 minutes", "Simmer for 30 minutes"},
+		},
+	})
+
+	return handler
+}
+
+func TestRecipesSort(t *testing.T) {
+	handler := newBrowseTestHandler(t)
+
+	tests := map[string][]string{
+		// titles sort without case
+		"":              {"apple Pie", "Banana Bread", "Pancakes", "Zucchini Soup"},
+		"sort=title":    {"apple Pie", "Banana Bread", "Pancakes", "Zucchini Soup"},
+		"sort=modified": {"apple Pie", "Zucchini Soup", "Banana Bread", "Pancakes"},
+		"sort=added":    {"Zucchini Soup", "apple Pie", "Banana Bread", "Pancakes"},
+		"sort=unknown":  {"apple Pie", "Banana Bread", "Pancakes", "Zucchini Soup"},
+	}
+
+	for rawQuery, expected := range tests {
+		_, titles := browse(t, handler, rawQuery)
+		if strings.Join(titles, ", ") != strings.Join(expected, ", ") {
+			t.Errorf("Expected %v for \"%s\", got %v", expected, rawQuery, titles)
+		}
+	}
+}
+
+func TestRecipesFacets(t *testing.T) {
+	handler := newBrowseTestHandler(t)
+
+	body, _ := browse(t, handler, "")
+	for _, term := range []string{
+		`Baking <mark class="tag">2</mark>`,
+		`Soups <mark class="tag">1</mark>`,
+		`dessert <mark class="tag">1</mark>`,
+		`Ada <mark class="tag">1</mark>`,
+		`English <mark class="tag">4</mark>`,
+		`350°F to 399°F <mark class="tag">1</mark>`,
+		`30 to 59 minutes <mark class="tag">2</mark>`,
+		`1 to 2 hours <mark class="tag">1</mark>`,
+	} {
+		if !strings.Contains(body, term) {
+			t.Errorf("Expected the facet term %s", term)
+		}
+	}
+
+	tests := map[string][]string{
+		"category=Baking": {"apple Pie", "Banana Bread"},
+		"tag=dessert":     {"apple Pie"},
+		"author=Ada":      {"Zucchini Soup"},
+		"oven=" + url.QueryEscape("350°F to 399°F"):               {"Zucchini Soup"},
+		"time=" + url.QueryEscape("30 to 59 minutes"):             {"apple Pie", "Zucchini Soup"},
+		"category=Baking&time=" + url.QueryEscape("1 to 2 hours"): {"Banana Bread"},
+		"category=Breakfast":                                      {},
+	}
+
+	for rawQuery, expected := range tests {
+		body, titles := browse(t, handler, rawQuery)
+		if strings.Join(titles, ", ") != strings.Join(expected, ", ") {
+			t.Errorf("Expected %v for \"%s\", got %v", expected, rawQuery, titles)
+		}
+
+		// an active filter can be cleared
+		if len(expected) > 0 && !strings.Contains(body, "<small>clear</small>") {
+			t.Errorf("Expected a link to clear the filter for \"%s\"", rawQuery)
+		}
+	}
+}
+
+func TestRecipesPagination(t *testing.T) {
+	handler := newBrowseTestHandler(t)
+	for i := 0; i < browsePageSize; i++ {
+		addTestRecipe(t, handler, &recipe.Recipe{
+			Title:    fmt.Sprintf("Stew %02d", i),
+			Category: "Soups",
+			Language: "en",
+		})
+	}
+
+	total := browsePageSize + 4
+	body, titles := browse(t, handler, "category=Soups&sort=title")
+	if len(titles) != browsePageSize || titles[0] != "Stew 00" {
+		t.Errorf("Expected a full first page starting with Stew 00, got %v", titles)
+	}
+
+	if !strings.Contains(body, fmt.Sprintf("<p>%d recipes</p>", browsePageSize+1)) {
+		t.Errorf("Expected the total over every page")
+	}
+
+	if !strings.Contains(body, `href="/recipes/?category=Soups&amp;page=2&amp;sort=title">Next</a>`) || strings.Contains(body, ">Previous</a>") {
+		t.Error("Expected only a next page link on the first page")
+	}
+
+	body, titles = browse(t, handler, "category=Soups&sort=title&page=2")
+	if strings.Join(titles, ", ") != "Zucchini Soup" {
+		t.Errorf("Expected Zucchini Soup on the second page, got %v", titles)
+	}
+
+	if !strings.Contains(body, `href="/recipes/?category=Soups&amp;page=1&amp;sort=title">Previous</a>`) || strings.Contains(body, ">Next</a>") {
+		t.Error("Expected only a previous page link on the last page")
+	}
+
+	_, titles = browse(t, handler, fmt.Sprintf("page=%d", total))
+	if len(titles) != 0 {
+		t.Errorf("Expected no recipes past the last page, got %v", titles)
+	}
+
+	// pages too large for their offset are the last page that can be asked for
+	for _, page := range []string{"9223372036854775807", "768614336404564651"} {
+		body, titles = browse(t, handler, "page="+page)
+		if len(titles) != 0 || !strings.Contains(body, ">Previous</a>") {
+			t.Errorf("Expected an empty page for page %s, got %v", page, titles)
+		}
+	}
+}
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blevesearch/bleve"
+	"github.com/tblyler/recipe-card/epub"
+	"github.com/tblyler/recipe-card/pdf"
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+const (
+	cookbookPattern = "/cookbook/"
+
+	// cookbookOtherCategory is the chapter of recipes that are not in a category
+	cookbookOtherCategory = "Other recipes"
+
+	// cookbookStyle is the CSS of every page of an EPUB cookbook
+	cookbookStyle = `body { font-family: serif; line-height: 1.4; }
+h1 { margin-bottom: 0.2em; }
+h2 { text-transform: capitalize; margin-bottom: 0.2em; }
+img { display: block; max-width: 100%; margin: 1em auto; }
+.author { font-style: italic; margin-top: 0; }
+.index p { margin: 0.2em 0; }
+`
+)
+
+// cookbookFormats are the content types of the files a cookbook can be written as
+var cookbookFormats = map[string]string{
+	"pdf":  "application/pdf",
+	"epub": "application/epub+zip",
+}
+
+// CookbookSelection picks the recipes of a cookbook, every field that is set must match
+type CookbookSelection struct {
+	// folder the recipes are in, including the folders below it
+	Category string
+	Tag      string
+	// search using the same syntax as the search box
+	Query string
+}
+
+// cookbookIndexEntry is an ingredient with the recipes that use it
+type cookbookIndexEntry struct {
+	name    string
+	recipes []*recipe.Recipe
+}
+
+// cookbookRecipes finds the recipes of the selection, grouped by category and then by title
+func (h *Handler) cookbookRecipes(selection CookbookSelection) ([]*recipe.Recipe, error) {
+	var matches map[string]bool
+	if selection.Query != "" {
+		// a cookbook has only what was asked for, so misspellings are not searched fuzzy
+		searchQuery, err := parseSearch(selection.Query, false)
+		if err != nil {
+			return nil, err
+		}
+
+		searchResults, err := h.idx.Search(bleve.NewSearchRequestOptions(searchQuery, h.recipeCount(), 0, false))
+		if err != nil {
+			return nil, err
+		}
+
+		matches = make(map[string]bool, len(searchResults.Hits))
+		for _, hit := range searchResults.Hits {
+			matches[hit.ID] = true
+		}
+	}
+
+	recipes := []*recipe.Recipe{}
+	h.lock.RLock()
+	for _, rec := range h.recipeSlice {
+		if matches != nil && !matches[rec.Title] {
+			continue
+		}
+
+		if selection.Category != "" && rec.Category != selection.Category && !strings.HasPrefix(rec.Category, selection.Category+"/") {
+			continue
+		}
+
+		if selection.Tag != "" && !hasTag(rec, selection.Tag) {
+			continue
+		}
+
+		recipes = append(recipes, rec)
+	}
+	h.lock.RUnlock()
+
+	sort.SliceStable(recipes, func(i, j int) bool {
+		if recipes[i].Category != recipes[j].Category {
+			// recipes without a category go last
+			if recipes[i].Category == "" || recipes[j].Category == "" {
+				return recipes[j].Category == ""
+			}
+
+			return recipes[i].Category < recipes[j].Category
+		}
+
+		return strings.ToLower(recipes[i].Title) < strings.ToLower(recipes[j].Title)
+	})
+
+	return recipes, nil
+}
+
+// hasTag checks if the recipe has the tag, ignoring case
+func hasTag(rec *recipe.Recipe, tag string) bool {
+	for _, recipeTag := range rec.Tags {
+		if strings.EqualFold(recipeTag, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cookbookCategory is the chapter a recipe is in
+func cookbookCategory(rec *recipe.Recipe) string {
+	if rec.Category == "" {
+		return cookbookOtherCategory
+	}
+
+	return rec.Category
+}
+
+// cookbookIngredientIndex lists every ingredient of the recipes alphabetically, with the recipes in cookbook order
+func cookbookIngredientIndex(recipes []*recipe.Recipe) []*cookbookIndexEntry {
+	entries := make(map[string]*cookbookIndexEntry)
+	for _, rec := range recipes {
+		for _, ingredient := range rec.Ingredients() {
+			entry, exists := entries[ingredient.Name]
+			if !exists {
+				entry = &cookbookIndexEntry{name: ingredient.Name}
+				entries[ingredient.Name] = entry
+			}
+
+			// an ingredient can be in a recipe more than once
+			if len(entry.recipes) == 0 || entry.recipes[len(entry.recipes)-1] != rec {
+				entry.recipes = append(entry.recipes, rec)
+			}
+		}
+	}
+
+	index := make([]*cookbookIndexEntry, 0, len(entries))
+	for _, entry := range entries {
+		index = append(index, entry)
+	}
+
+	sort.Slice(index, func(i, j int) bool {
+		return index[i].name < index[j].name
+	})
+
+	return index
+}
+
+// layoutCookbookPDF lays out the title page, contents, recipes and index of ingredients
+// the contents use pages for the page of each recipe, the pages the recipes ended up on are returned
+func layoutCookbookPDF(title string, recipes []*recipe.Recipe, pages map[*recipe.Recipe]int) (*pdf.Document, map[*recipe.Recipe]int, error) {
+	document := pdf.NewDocument(pdf.Info{Title: title, Subject: "Cookbook"})
+	document.Footer = func(page int) string {
+		// the title page is not numbered
+		if page == 1 {
+			return ""
+		}
+
+		return strconv.Itoa(page)
+	}
+
+	document.Space(pdf.PageHeight / 3)
+	document.Paragraph(title, pdf.Bold, 32)
+	document.Paragraph(fmt.Sprintf("%d recipes", len(recipes)), pdf.Regular, 14)
+
+	document.NewPage()
+	document.Paragraph("Contents", pdf.Bold, 20)
+	category := ""
+	for _, rec := range recipes {
+		if cookbookCategory(rec) != category {
+			category = cookbookCategory(rec)
+			document.Space(8)
+			document.Paragraph(category, pdf.Bold, 13)
+		}
+
+		page := ""
+		if pages[rec] > 0 {
+			page = strconv.Itoa(pages[rec])
+		}
+
+		document.Row(rec.Title, page, pdf.Regular, 11)
+	}
+
+	found := make(map[*recipe.Recipe]int, len(recipes))
+	for _, rec := range recipes {
+		document.NewPage()
+		found[rec] = document.Page()
+		err := writeRecipe(document, rec, false)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	document.NewPage()
+	document.Paragraph("Index of ingredients", pdf.Bold, 20)
+	document.Space(8)
+	for _, entry := range cookbookIngredientIndex(recipes) {
+		entryPages := make([]string, 0, len(entry.recipes))
+		for _, rec := range entry.recipes {
+			entryPages = append(entryPages, strconv.Itoa(found[rec]))
+		}
+
+		// ingredients in many recipes have their pages wrapped below them
+		numbers := strings.Join(entryPages, ", ")
+		if pdf.TextWidth(numbers, pdf.Regular, 10) > (pdf.PageWidth-2*pdf.Margin)/2 {
+			document.Paragraph(entry.name, pdf.Regular, 10)
+			document.Indented(numbers, pdf.Regular, 10, 12)
+			continue
+		}
+
+		document.Row(entry.name, numbers, pdf.Regular, 10)
+	}
+
+	return document, found, nil
+}
+
+// WriteCookbookPDF writes the recipes as a PDF cookbook with a table of contents and an index of ingredients
+func WriteCookbookPDF(writer io.Writer, title string, recipes []*recipe.Recipe) error {
+	// the contents are a line per recipe whatever the page numbers are, so laying
+	// out twice puts every recipe on the page the contents say
+	_, pages, err := layoutCookbookPDF(title, recipes, nil)
+	if err != nil {
+		return err
+	}
+
+	document, _, err := layoutCookbookPDF(title, recipes, pages)
+	if err != nil {
+		return err
+	}
+
+	_, err = document.WriteTo(writer)
+	return err
+}
+
+// WriteCookbookEPUB writes the recipes as an EPUB cookbook with a chapter per recipe and an index of ingredients
+func WriteCookbookEPUB(writer io.Writer, title string, recipes []*recipe.Recipe) error {
+	// the same recipes make the same identifier
+	identifier := sha256.New()
+	io.WriteString(identifier, title)
+	for _, rec := range recipes {
+		io.WriteString(identifier, "\x00"+rec.Title)
+	}
+
+	sum := identifier.Sum(nil)
+	book := &epub.Book{
+		Title:      title,
+		Identifier: fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16]),
+		Style:      cookbookStyle,
+	}
+
+	chapters := make(map[*recipe.Recipe]string, len(recipes))
+	for i, rec := range recipes {
+		chapters[rec] = epub.ChapterPath(i)
+		if rec.Modified.After(book.Modified) {
+			book.Modified = rec.Modified
+		}
+
+		body := strings.Builder{}
+		body.WriteString("<h1>" + epub.Escape(rec.Title) + "</h1>")
+		if rec.Author != "" {
+			body.WriteString(`<p class="author">` + epub.Escape(rec.Author) + "</p>")
+		}
+
+		if len(rec.Image) > 0 {
+			image, err := resizeToJPEG(rec.Image, exportImageWidth)
+			if err != nil {
+				return fmt.Errorf("stock image of %s: %s", rec.Title, err)
+			}
+
+			name := fmt.Sprintf("recipe%03d.jpg", i+1)
+			book.Images = append(book.Images, &epub.Image{Name: name, MediaType: "image/jpeg", Data: image})
+			body.WriteString(`<img src="` + epub.ImagePath(name) + `" alt="` + epub.Escape(rec.Title) + `"/>`)
+		}
+
+		for _, category := range recipe.ValidCategoriesOrder {
+			lines, exists := rec.Info[category]
+			if !exists {
+				continue
+			}
+
+			body.WriteString("<h2>" + epub.Escape(category) + "</h2>")
+			for _, line := range lines {
+				body.WriteString("<p>" + epub.Escape(line) + "</p>")
+			}
+		}
+
+		book.Chapters = append(book.Chapters, &epub.Chapter{
+			Title: rec.Title,
+			Group: cookbookCategory(rec),
+			Body:  body.String(),
+		})
+	}
+
+	index := strings.Builder{}
+	index.WriteString(`<h1>Index of ingredients</h1><div class="index">`)
+	for _, entry := range cookbookIngredientIndex(recipes) {
+		links := make([]string, 0, len(entry.recipes))
+		for _, rec := range entry.recipes {
+			links = append(links, `<a href="`+chapters[rec]+`">`+epub.Escape(rec.Title)+"</a>")
+		}
+
+		index.WriteString("<p>" + epub.Escape(entry.name) + ": " + strings.Join(links, ", ") + "</p>")
+	}
+
+	index.WriteString("</div>")
+	book.Chapters = append(book.Chapters, &epub.Chapter{Title: "Index of ingredients", Body: index.String()})
+
+	return book.Write(writer)
+}
+
+// writeCookbook writes the recipes as a cookbook in the format, pdf or epub
+func writeCookbook(writer io.Writer, format, title string, recipes []*recipe.Recipe) error {
+	switch format {
+	case "pdf":
+		return WriteCookbookPDF(writer, title, recipes)
+	case "epub":
+		return WriteCookbookEPUB(writer, title, recipes)
+	}
+
+	return fmt.Errorf("format must be pdf or epub, not %q", format)
+}
+
+// Cookbook handles compiling a cookbook, the form is shown until a format is chosen
+func (h *Handler) Cookbook(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	selection := CookbookSelection{
+		Category: strings.TrimSpace(query.Get("category")),
+		Tag:      strings.TrimSpace(query.Get("tag")),
+		Query:    strings.TrimSpace(query.Get("q")),
+	}
+
+	tmplCookbook := &TemplateCookbook{
+		Title:    strings.TrimSpace(query.Get("title")),
+		Tag:      selection.Tag,
+		Query:    selection.Query,
+		Category: selection.Category,
+	}
+
+	if tmplCookbook.Title == "" {
+		tmplCookbook.Title = "Family Cookbook"
+	}
+
+	format := query.Get("format")
+	for _, name := range []string{"pdf", "epub"} {
+		tmplCookbook.Formats = append(tmplCookbook.Formats, &TemplateOption{
+			Value:    name,
+			Label:    strings.ToUpper(name),
+			Selected: name == format || (format == "" && name == "pdf"),
+		})
+	}
+
+	status := http.StatusOK
+	if format != "" {
+		contentType, exists := cookbookFormats[format]
+		recipes, err := h.cookbookRecipes(selection)
+		switch {
+		case !exists:
+			status = http.StatusBadRequest
+			tmplCookbook.Error = "Choose PDF or EPUB"
+		case err != nil:
+			if syntaxErr, ok := err.(*SearchSyntaxError); ok {
+				status = http.StatusBadRequest
+				tmplCookbook.Error = syntaxErr.Error()
+			} else {
+				h.logger.WithError(err).WithField("query", selection.Query).Errorln("Failed to search for the cookbook")
+				status = http.StatusInternalServerError
+				tmplCookbook.Error = "Unable to search right now: " + err.Error()
+			}
+		case len(recipes) == 0:
+			status = http.StatusBadRequest
+			tmplCookbook.Error = "No recipes match, choose fewer filters"
+		default:
+			// lay out the whole cookbook first, so a failure is not sent as half a file
+			buf := new(bytes.Buffer)
+			err = writeCookbook(buf, format, tmplCookbook.Title, recipes)
+			if err == nil {
+				w.Header().Set("Content-Type", contentType)
+				w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", recipe.FileName(tmplCookbook.Title)+"."+format))
+				buf.WriteTo(w)
+				return
+			}
+
+			h.logger.WithError(err).WithField("title", tmplCookbook.Title).Errorln("Failed to compile cookbook")
+			status = http.StatusInternalServerError
+			tmplCookbook.Error = "Unable to compile the cookbook: " + err.Error()
+		}
+	}
+
+	for _, category := range h.recipeCategories() {
+		tmplCookbook.Categories = append(tmplCookbook.Categories, &TemplateOption{
+			Value:    category,
+			Label:    category,
+			Selected: category == selection.Category,
+		})
+	}
+
+	tmplCookbook.Tags = h.recipeTags()
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	h.templates.ExecuteTemplate(w, "cookbook", &TemplateData{
+		PageTitle: "Recipe Card - Cookbook",
+		Cookbook:  tmplCookbook,
+	})
+}
+
+// recipeTags lists the tags of every recipe, lowercase and sorted
+func (h *Handler) recipeTags() []string {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, rec := range h.recipeSlice {
+		for _, tag := range rec.Tags {
+			tag = strings.ToLower(tag)
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	sort.Strings(tags)
+
+	return tags
+}
+
+// compileCookbook writes the selected recipes to the cookbook at output for the command line
+// the format is the extension of output
+func (h *Handler) compileCookbook(title string, selection CookbookSelection, output string) error {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(output)), ".")
+	if _, exists := cookbookFormats[format]; !exists {
+		return fmt.Errorf("the cookbook must end in .pdf or .epub, not %q", output)
+	}
+
+	recipes, err := h.cookbookRecipes(selection)
+	if err != nil {
+		return err
+	}
+
+	if len(recipes) == 0 {
+		return fmt.Errorf("no recipes match")
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	err = writeCookbook(file, format, title, recipes)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(output)
+	}
+
+	return err
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCookbookRecipes(t *testing.T) {
+	handler := newTestHandler(t)
+
+	for _, test := range []struct {
+		selection CookbookSelection
+		titles    []string
+	}{
+		// recipes without a category go last
+		{CookbookSelection{}, []string{"Banana Bread", "Pancakes"}},
+		{CookbookSelection{Category: "Baking"}, []string{"Banana Bread"}},
+		{CookbookSelection{Category: "Bak"}, []string{}},
+		{CookbookSelection{Tag: "bread"}, []string{"Banana Bread"}},
+		{CookbookSelection{Query: "milk"}, []string{"Pancakes"}},
+		// the search box would find milk fuzzy
+		{CookbookSelection{Query: "mulk"}, []string{}},
+		{CookbookSelection{Query: "flour", Tag: "bread"}, []string{"Banana Bread"}},
+	} {
+		recipes, err := handler.cookbookRecipes(test.selection)
+		if err != nil {
+			t.Errorf("Failed to select %+v: %s", test.selection, err)
+			continue
+		}
+
+		titles := []string{}
+		for _, rec := range recipes {
+			titles = append(titles, rec.Title)
+		}
+
+		if strings.Join(titles, ", ") != strings.Join(test.titles, ", ") {
+			t.Errorf("Expected %v for %+v, got %v", test.titles, test.selection, titles)
+		}
+	}
+
+	_, err := handler.cookbookRecipes(CookbookSelection{Query: "title:"})
+	if _, ok := err.(*SearchSyntaxError); !ok {
+		t.Errorf("Expected a SearchSyntaxError, got %v", err)
+	}
+}
+
+func TestCookbookIngredientIndex(t *testing.T) {
+	handler := newTestHandler(t)
+	recipes, err := handler.cookbookRecipes(CookbookSelection{})
+	if err != nil {
+		t.Fatal("Failed to select recipes", err)
+	}
+
+	for _, entry := range cookbookIngredientIndex(recipes) {
+		if entry.name != "flour" {
+			continue
+		}
+
+		if len(entry.recipes) != 2 || entry.recipes[0].Title != "Banana Bread" || entry.recipes[1].Title != "Pancakes" {
+			t.Errorf("Expected flour in both recipes, got %d", len(entry.recipes))
+		}
+
+		return
+	}
+
+	t.Error("Expected flour in the index")
+}
+
+func TestCookbook(t *testing.T) {
+	handler := newTestHandler(t)
+	templates, err := NewTemplate(nil)
+	if err != nil {
+		t.Fatal("Failed to parse templates", err)
+	}
+
+	handler.templates = templates
+
+	recorder := httptest.NewRecorder()
+	handler.Cookbook(recorder, httptest.NewRequest(http.MethodGet, "/cookbook/", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for the form, got %d", recorder.Code)
+	}
+
+	if body := recorder.Body.String(); !strings.Contains(body, `<option value="Baking">`) || !strings.Contains(body, `<option value="bread">`) {
+		t.Error("Expected the categories and tags in the form")
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.Cookbook(recorder, httptest.NewRequest(http.MethodGet, "/cookbook/?format=pdf&title=Family", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for a PDF, got %d", recorder.Code)
+	}
+
+	data := recorder.Body.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.Contains(data, []byte("/Title (Family)")) {
+		t.Error("Expected a PDF titled Family")
+	}
+
+	// title page, contents, two recipes and the index
+	if pages := bytes.Count(data, []byte("/Type /Page ")); pages != 5 {
+		t.Errorf("Expected 5 pages, got %d", pages)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.Cookbook(recorder, httptest.NewRequest(http.MethodGet, "/cookbook/?format=epub&title=Family&tag=bread", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for an EPUB, got %d", recorder.Code)
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/epub+zip" {
+		t.Errorf("Expected an EPUB, got \"%s\"", contentType)
+	}
+
+	data = recorder.Body.Bytes()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal("Failed to read the EPUB", err)
+	}
+
+	chapters := map[string]string{}
+	for _, file := range reader.File {
+		contents, err := file.Open()
+		if err != nil {
+			t.Fatal("Failed to open", file.Name, err)
+		}
+
+		text, err := ioutil.ReadAll(contents)
+		contents.Close()
+		if err != nil {
+			t.Fatal("Failed to read", file.Name, err)
+		}
+
+		chapters[file.Name] = string(text)
+	}
+
+	if !strings.Contains(chapters["OEBPS/chapter001.xhtml"], "<h1>Banana Bread</h1>") {
+		t.Error("Expected Banana Bread as the first chapter")
+	}
+
+	if !strings.Contains(chapters["OEBPS/chapter002.xhtml"], `<a href="chapter001.xhtml">Banana Bread</a>`) {
+		t.Error("Expected the index to link to Banana Bread")
+	}
+
+	if _, exists := chapters["OEBPS/chapter003.xhtml"]; exists {
+		t.Error("Expected only Banana Bread to have the tag")
+	}
+
+	for _, query := range []string{"format=doc", "format=pdf&tag=soup", "format=pdf&q=title:"} {
+		recorder = httptest.NewRecorder()
+		handler.Cookbook(recorder, httptest.NewRequest(http.MethodGet, "/cookbook/?"+query, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", query, recorder.Code)
+		}
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.Cookbook(recorder, httptest.NewRequest(http.MethodGet, "/cookbook/?format=pdf&q=mulk", nil))
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "No recipes match") {
+		t.Errorf("Expected no recipes for a misspelled search, got %d", recorder.Code)
+	}
+}
@@ -8,12 +8,16 @@ import (
 	"io"
 	"io/ioutil"
 	"strings"
+	"time"
 )
 
 const (
 	// xmlFileName is the one true XML file in a docx file that has
 	// the textual information we desire
 	xmlFileName = "word/document.xml"
+
+	// corePropertiesFileName holds the title, author, keywords, etc of the docx
+	corePropertiesFileName = "docprops/core.xml"
 )
 
 var (
@@ -24,8 +28,35 @@ var (
 // Docx parses docx-formated readers
 // this is go routine safe
 type Docx struct {
-	xmlData []byte
-	Image   []byte
+	xmlData    []byte
+	Image      []byte
+	Properties Properties
+}
+
+// Properties are the core document properties of a docx file
+type Properties struct {
+	Title       string    `xml:"title"`
+	Subject     string    `xml:"subject"`
+	Creator     string    `xml:"creator"`
+	Keywords    string    `xml:"keywords"`
+	Description string    `xml:"description"`
+	Language    string    `xml:"language"`
+	Created     time.Time `xml:"-"`
+	Modified    time.Time `xml:"-"`
+}
+
+// KeywordList splits the comma or semicolon separated keywords
+func (p Properties) KeywordList() (keywords []string) {
+	for _, keyword := range strings.FieldsFunc(p.Keywords, func(r rune) bool {
+		return r == ',' || r == ';'
+	}) {
+		keyword = strings.TrimSpace(keyword)
+		if keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	return
 }
 
 // NewDocx creates a new Docx instance with data from the given reader
@@ -41,10 +72,6 @@ func NewDocx(reader io.ReaderAt, size int64) (doc *Docx, err error) {
 	// find the xmlFileName file in the zip
 	var fileReader io.ReadCloser
 	for _, file := range zipReader.File {
-		if doc.xmlData != nil && doc.Image != nil {
-			return
-		}
-
 		lowerFileName := strings.ToLower(file.Name)
 		if doc.Image == nil && (strings.HasSuffix(lowerFileName, ".jpg") || strings.HasSuffix(lowerFileName, ".jpeg")) {
 			fileReader, err = file.Open()
@@ -72,6 +99,9 @@ func NewDocx(reader io.ReaderAt, size int64) (doc *Docx, err error) {
 			if err != nil {
 				return
 			}
+		} else if lowerFileName == corePropertiesFileName {
+			// the core properties are optional, ignore bad ones
+			doc.Properties, _ = parseProperties(file)
 		}
 	}
 
@@ -82,6 +112,34 @@ func NewDocx(reader io.ReaderAt, size int64) (doc *Docx, err error) {
 	return nil, ErrMissingDocument
 }
 
+// parseProperties decodes the core properties XML file of a docx
+func parseProperties(file *zip.File) (props Properties, err error) {
+	reader, err := file.Open()
+	if err != nil {
+		return
+	}
+
+	defer reader.Close()
+
+	// dates are decoded as strings since empty elements are common
+	raw := struct {
+		Properties
+		Created  string `xml:"created"`
+		Modified string `xml:"modified"`
+	}{}
+
+	err = xml.NewDecoder(reader).Decode(&raw)
+	if err != nil {
+		return
+	}
+
+	props = raw.Properties
+	props.Created, _ = time.Parse(time.RFC3339, strings.TrimSpace(raw.Created))
+	props.Modified, _ = time.Parse(time.RFC3339, strings.TrimSpace(raw.Modified))
+
+	return
+}
+
 // Text returns each line of (unformatted) text from the docx xml
 func (d *Docx) Text() (lines []string, err error) {
 	// create an XML decoder for the raw xml data
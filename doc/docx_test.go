@@ -608,3 +608,63 @@ func TestText(t *testing.T) {
 		}
 	}
 }
+
+func TestProperties(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(buf)
+	files := map[string]string{
+		"word/document.xml":     `<w:document><w:body><w:p><w:r><w:t>Recipe</w:t></w:r></w:p></w:body></w:document>`,
+		"word/media/image1.jpg": "not really a jpeg",
+		"docProps/core.xml": `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">` +
+			`<dc:title>Pound Cake</dc:title><dc:creator>Grandma</dc:creator><cp:keywords>dessert; cake,  baking ,</cp:keywords>` +
+			`<dc:language>en-US</dc:language><dcterms:created></dcterms:created><dcterms:modified>2017-06-30T13:32:14Z</dcterms:modified></cp:coreProperties>`,
+	}
+
+	for name, data := range files {
+		fileWriter, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatal("Failed to create zip writer", err)
+		}
+
+		io.WriteString(fileWriter, data)
+	}
+
+	zipWriter.Close()
+
+	doc, err := NewDocx(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal("Failed to open valid docx data", err)
+	}
+
+	if doc.Properties.Title != "Pound Cake" {
+		t.Errorf("Title != \"Pound Cake\": \"%s\"", doc.Properties.Title)
+	}
+
+	if doc.Properties.Creator != "Grandma" {
+		t.Errorf("Creator != \"Grandma\": \"%s\"", doc.Properties.Creator)
+	}
+
+	if doc.Properties.Language != "en-US" {
+		t.Errorf("Language != \"en-US\": \"%s\"", doc.Properties.Language)
+	}
+
+	if !doc.Properties.Created.IsZero() {
+		t.Error("Created should be zero, got", doc.Properties.Created)
+	}
+
+	if doc.Properties.Modified.Year() != 2017 {
+		t.Error("Modified should be in 2017, got", doc.Properties.Modified)
+	}
+
+	expected := []string{"dessert", "cake", "baking"}
+	keywords := doc.Properties.KeywordList()
+	if len(keywords) != len(expected) {
+		t.Fatalf("len(keywords) != len(expected): %d != %d", len(keywords), len(expected))
+	}
+
+	for i, keyword := range keywords {
+		if keyword != expected[i] {
+			t.Errorf("keyword != expected[%d]: \"%s\" != \"%s\"", i, keyword, expected[i])
+		}
+	}
+}
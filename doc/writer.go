@@ -0,0 +1,158 @@
+package doc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// imageFileName is where the image of a written docx is stored
+	imageFileName = "word/media/image1.jpg"
+
+	// imageWidthEMU is the width of the image in a written docx, 3 inches
+	// in the English Metric Units Word measures drawings with
+	imageWidthEMU = 3 * 914400
+)
+
+// Paragraph is a line of a docx being written
+type Paragraph struct {
+	Text string
+	// Heading paragraphs are bold and larger
+	Heading bool
+}
+
+// escapeXML escapes text for use in XML character data and attributes
+func escapeXML(text string) string {
+	buf := new(bytes.Buffer)
+	xml.EscapeText(buf, []byte(text))
+	return buf.String()
+}
+
+// WriteDocx writes a minimal docx with the JPEG image followed by the paragraphs
+// NewDocx is able to read everything that is written
+func WriteDocx(writer io.Writer, paragraphs []Paragraph, image []byte, props Properties) error {
+	config, err := jpeg.DecodeConfig(bytes.NewReader(image))
+	if err != nil {
+		return fmt.Errorf("image must be a JPEG: %s", err)
+	}
+
+	if config.Width == 0 || config.Height == 0 {
+		return fmt.Errorf("image must not be empty")
+	}
+
+	zipWriter := zip.NewWriter(writer)
+	files := []struct {
+		name string
+		data string
+	}{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", packageRelsXML},
+		{"word/_rels/document.xml.rels", documentRelsXML},
+		{xmlFileName, documentXML(paragraphs, imageWidthEMU, imageWidthEMU*config.Height/config.Width)},
+		{"docProps/core.xml", corePropertiesXML(props)},
+		{imageFileName, string(image)},
+	}
+
+	for _, file := range files {
+		fileWriter, err := zipWriter.Create(file.name)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(fileWriter, file.data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+// documentXML creates the document body with an inline image of the given size
+func documentXML(paragraphs []Paragraph, width, height int) string {
+	body := strings.Builder{}
+	body.WriteString(fmt.Sprintf(documentImageXML, width, height, width, height))
+	for _, paragraph := range paragraphs {
+		body.WriteString("<w:p><w:r>")
+		if paragraph.Heading {
+			body.WriteString(`<w:rPr><w:b/><w:sz w:val="28"/></w:rPr>`)
+		}
+
+		body.WriteString(`<w:t xml:space="preserve">`)
+		body.WriteString(escapeXML(paragraph.Text))
+		body.WriteString("</w:t></w:r></w:p>")
+	}
+
+	return xml.Header + `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"` +
+		` xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"` +
+		` xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"` +
+		` xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"` +
+		` xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">` +
+		"<w:body>" + body.String() + "</w:body></w:document>"
+}
+
+// corePropertiesXML creates the core properties, dates are left out when zero
+func corePropertiesXML(props Properties) string {
+	element := func(name, value string) string {
+		if value == "" {
+			return ""
+		}
+
+		return "<" + name + ">" + escapeXML(value) + "</" + name + ">"
+	}
+
+	date := func(name string, value time.Time) string {
+		if value.IsZero() {
+			return ""
+		}
+
+		return "<" + name + ` xsi:type="dcterms:W3CDTF">` + value.UTC().Format(time.RFC3339) + "</" + name + ">"
+	}
+
+	return xml.Header + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"` +
+		` xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/"` +
+		` xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		element("dc:title", props.Title) +
+		element("dc:subject", props.Subject) +
+		element("dc:creator", props.Creator) +
+		element("cp:keywords", props.Keywords) +
+		element("dc:description", props.Description) +
+		element("dc:language", props.Language) +
+		date("dcterms:created", props.Created) +
+		date("dcterms:modified", props.Modified) +
+		"</cp:coreProperties>"
+}
+
+const (
+	contentTypesXML = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Default Extension="jpg" ContentType="image/jpeg"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+		`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+		`</Types>`
+
+	packageRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+		`</Relationships>`
+
+	documentRelsXML = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/image1.jpg"/>` +
+		`</Relationships>`
+
+	// documentImageXML is a paragraph with the image inline, formatted with
+	// the width and height in EMU twice
+	documentImageXML = `<w:p><w:r><w:drawing><wp:inline><wp:extent cx="%d" cy="%d"/><wp:docPr id="1" name="Picture 1"/>` +
+		`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture"><pic:pic>` +
+		`<pic:nvPicPr><pic:cNvPr id="1" name="image1.jpg"/><pic:cNvPicPr/></pic:nvPicPr>` +
+		`<pic:blipFill><a:blip r:embed="rId1"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>` +
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>` +
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r></w:p>`
+)
@@ -0,0 +1,77 @@
+package doc
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+	"time"
+)
+
+func TestWriteDocx(t *testing.T) {
+	img := new(bytes.Buffer)
+	err := jpeg.Encode(img, image.NewGray(image.Rect(0, 0, 40, 30)), nil)
+	if err != nil {
+		t.Fatal("Failed to encode image", err)
+	}
+
+	created := time.Date(2018, 12, 24, 8, 0, 0, 0, time.UTC)
+	paragraphs := []Paragraph{
+		{Text: "Recipe", Heading: true},
+		{Text: "Mac & Cheese"},
+		{Text: "Ingredients:", Heading: true},
+		{Text: "1 lb <elbow> macaroni"},
+	}
+
+	buf := new(bytes.Buffer)
+	err = WriteDocx(buf, paragraphs, img.Bytes(), Properties{
+		Title:    "Mac & Cheese",
+		Creator:  "Grandpa",
+		Keywords: "pasta, dinner",
+		Created:  created,
+	})
+	if err != nil {
+		t.Fatal("Failed to write docx", err)
+	}
+
+	doc, err := NewDocx(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal("Failed to read written docx", err)
+	}
+
+	if !bytes.Equal(doc.Image, img.Bytes()) {
+		t.Error("Image was not written")
+	}
+
+	lines, err := doc.Text()
+	if err != nil {
+		t.Fatal("Failed to get text", err)
+	}
+
+	if len(lines) != len(paragraphs) {
+		t.Fatalf("len(lines) != len(paragraphs): %d != %d", len(lines), len(paragraphs))
+	}
+
+	for i, line := range lines {
+		if line != paragraphs[i].Text {
+			t.Errorf("line != paragraphs[%d]: \"%s\" != \"%s\"", i, line, paragraphs[i].Text)
+		}
+	}
+
+	if doc.Properties.Title != "Mac & Cheese" {
+		t.Errorf("Title != \"Mac & Cheese\": \"%s\"", doc.Properties.Title)
+	}
+
+	if doc.Properties.Creator != "Grandpa" {
+		t.Errorf("Creator != \"Grandpa\": \"%s\"", doc.Properties.Creator)
+	}
+
+	if !doc.Properties.Created.Equal(created) {
+		t.Errorf("Created != %s: %s", created, doc.Properties.Created)
+	}
+
+	err = WriteDocx(new(bytes.Buffer), paragraphs, []byte("not a jpeg"), Properties{})
+	if err == nil {
+		t.Error("Expected an error for an image that is not a JPEG")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"path/filepath"
+	"sort"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+const (
+	duplicatesPattern = "/duplicates/"
+
+	// duplicateDistance is the most bits the hashes of two photos of the same card differ by
+	duplicateDistance = 6
+)
+
+// duplicateScan is a scan of a recipe with its perceptual hash
+type duplicateScan struct {
+	recipe *recipe.Recipe
+	path   string
+	hash   uint64
+}
+
+// duplicateScans groups scans that look alike, the largest groups first
+// a scan is in a group when it is close to any other scan in it
+func (h *Handler) duplicateScans() [][]*duplicateScan {
+	scans := []*duplicateScan{}
+	h.lock.RLock()
+	for _, rec := range h.recipeSlice {
+		for _, path := range rec.ScanPaths {
+			hash, exists := rec.ScanHashes[path]
+			if exists {
+				scans = append(scans, &duplicateScan{recipe: rec, path: path, hash: hash})
+			}
+		}
+	}
+	h.lock.RUnlock()
+
+	// union find of the scans, parents[i] == i for the first scan of a group
+	parents := make([]int, len(scans))
+	for i := range parents {
+		parents[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+
+		return parents[i]
+	}
+
+	for i := range scans {
+		for j := i + 1; j < len(scans); j++ {
+			if recipe.HashDistance(scans[i].hash, scans[j].hash) <= duplicateDistance {
+				parents[find(j)] = find(i)
+			}
+		}
+	}
+
+	groupIndexes := make(map[int]int)
+	groups := [][]*duplicateScan{}
+	for i, scan := range scans {
+		root := find(i)
+		index, exists := groupIndexes[root]
+		if !exists {
+			index = len(groups)
+			groupIndexes[root] = index
+			groups = append(groups, nil)
+		}
+
+		groups[index] = append(groups[index], scan)
+	}
+
+	duplicates := groups[:0]
+	for _, group := range groups {
+		if len(group) > 1 {
+			duplicates = append(duplicates, group)
+		}
+	}
+
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return len(duplicates[i]) > len(duplicates[j])
+	})
+
+	return duplicates
+}
+
+// Duplicates handles the report of scans that look like the same card
+func (h *Handler) Duplicates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	tmplData := &TemplateData{
+		PageTitle: "Recipe Card - Duplicate scans",
+	}
+
+	for _, group := range h.duplicateScans() {
+		tmplGroup := &TemplateDuplicateGroup{}
+		seen := make(map[string]bool)
+		for _, scan := range group {
+			urlPath, err := h.pathToURL(scan.path, imagePattern)
+			if err != nil {
+				continue
+			}
+
+			tmplRecipe := h.recipeToTemplateRecipe(scan.recipe)
+			tmplGroup.Scans = append(tmplGroup.Scans, &TemplateDuplicateScan{
+				Name:   filepath.Base(scan.path),
+				Recipe: tmplRecipe,
+				Image: &TemplateImage{
+					URL:       urlPath,
+					Thumbnail: thumbnailURL(urlPath, thumbnailWidths[0]),
+					Srcset:    thumbnailSrcset(urlPath),
+				},
+			})
+
+			if !seen[scan.recipe.Title] {
+				seen[scan.recipe.Title] = true
+				tmplGroup.Recipes = append(tmplGroup.Recipes, tmplRecipe)
+			}
+		}
+
+		tmplData.DuplicateGroups = append(tmplData.DuplicateGroups, tmplGroup)
+	}
+
+	h.templates.ExecuteTemplate(w, "duplicates", tmplData)
+}
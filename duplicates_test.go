@@ -0,0 +1,45 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+func TestDuplicateScans(t *testing.T) {
+	handler := &Handler{recipePath: filepath.Join(string(filepath.Separator), "recipes")}
+	scan := func(name string) string {
+		return filepath.Join(handler.recipePath, name)
+	}
+
+	handler.recipeSlice = []*recipe.Recipe{
+		{
+			Title:      "Banana Bread",
+			ScanPaths:  []string{scan("a.jpg"), scan("b.jpg"), scan("broken.jpg")},
+			ScanHashes: map[string]uint64{scan("a.jpg"): 0xff00ff00, scan("b.jpg"): 0x0f0f0f0f},
+		},
+		{
+			Title:     "Pancakes",
+			ScanPaths: []string{scan("c.jpg"), scan("d.jpg"), scan("e.jpg")},
+			// c is close to a, d is close to c but not a, e is like nothing
+			ScanHashes: map[string]uint64{scan("c.jpg"): 0xff00ff07, scan("d.jpg"): 0xff00ffff, scan("e.jpg"): 0xaaaaaaaa00000000},
+		},
+	}
+
+	groups := handler.duplicateScans()
+	if len(groups) != 1 {
+		t.Fatalf("Expected 1 group of duplicates, got %d", len(groups))
+	}
+
+	expected := []string{scan("a.jpg"), scan("c.jpg"), scan("d.jpg")}
+	if len(groups[0]) != len(expected) {
+		t.Fatalf("Expected %d duplicates, got %d", len(expected), len(groups[0]))
+	}
+
+	for i, duplicate := range groups[0] {
+		if duplicate.path != expected[i] {
+			t.Errorf("Expected duplicate %s, got %s", expected[i], duplicate.path)
+		}
+	}
+}
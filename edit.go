@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"image/jpeg"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+const (
+	newRecipePattern = "/recipes/new"
+
+	// recipeEditSuffix is added to a recipe page URL for its edit form
+	recipeEditSuffix = "/edit"
+
+	// newRecipeMaxImageSize is the largest stock image accepted for a new recipe
+	newRecipeMaxImageSize = 10 << 20
+)
+
+// RecipeEditError is a user facing reason a recipe edit was not saved
+type RecipeEditError struct {
+	Status int
+	Reason string
+}
+
+func (e *RecipeEditError) Error() string {
+	return e.Reason
+}
+
+// editRecipeURL is the relative URL of the edit form for the recipe title
+func editRecipeURL(title string) string {
+	return recipePattern + url.PathEscape(title) + recipeEditSuffix
+}
+
+// editRecipe saves the edit of the recipe titled id to its sidecar, then reparses and reindexes it
+// edits are done one at a time so a title can not be taken twice
+func (h *Handler) editRecipe(id, version, title string, info map[string][]string) (*recipe.Recipe, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, &RecipeEditError{http.StatusBadRequest, "The title must not be empty"}
+	}
+
+	h.edits.Lock()
+	defer h.edits.Unlock()
+
+	h.lock.RLock()
+	rec, exists := h.recipes[id]
+	other, taken := h.recipes[title]
+	h.lock.RUnlock()
+
+	if !exists {
+		return nil, &RecipeEditError{http.StatusNotFound, "There is no recipe named \"" + id + "\""}
+	}
+
+	if taken && other != rec {
+		return nil, &RecipeEditError{http.StatusBadRequest, "There is already a recipe named \"" + title + "\""}
+	}
+
+	err := rec.Edit(version, title, info)
+	if err == recipe.ErrEditConflict {
+		return nil, &RecipeEditError{
+			http.StatusConflict,
+			"Someone else changed this recipe while you were editing it. Saving again will replace their changes.",
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// parsing reads every file of the recipe, so readers are only held up to swap it in
+	edited := &recipe.Recipe{
+		DocxPath: rec.DocxPath,
+		Category: rec.Category,
+	}
+
+	err = edited.ParseFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	if edited.Title != rec.Title {
+		err = h.idx.Delete(rec.Title)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	h.lock.Lock()
+	h.replaceRecipe(rec, edited)
+	h.lock.Unlock()
+
+	err = h.idx.Index(edited.Title, newRecipeDocument(edited))
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.WithField("recipeTitle", edited.Title).Infoln("Edited and reindexed")
+
+	return edited, nil
+}
+
+// EditRecipe handles the form for a recipe's title and sections
+func (h *Handler) EditRecipe(w http.ResponseWriter, r *http.Request, id string) {
+	w.Header().Set("Content-Type", "text/html")
+
+	rec, exists := h.getRecipe(id)
+	if !exists {
+		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+		return
+	}
+
+	version, err := rec.Version()
+	if err != nil {
+		h.logger.WithError(err).WithField("recipeTitle", id).Errorln("Failed to get recipe version")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	tmplData := &TemplateData{
+		PageTitle: "Recipe Card - Edit - " + id,
+		Edit: &TemplateEdit{
+			Action:    editRecipeURL(id),
+			CancelURL: recipePattern + url.PathEscape(id),
+			Version:   version,
+			Title:     rec.Title,
+		},
+	}
+
+	info := rec.Info
+	if r.Method == http.MethodPost {
+		info = make(map[string][]string)
+		for _, category := range recipe.ValidCategoriesOrder {
+			text := strings.Replace(r.PostFormValue(category), "\r\n", "\n", -1)
+			info[category] = strings.Split(text, "\n")
+		}
+
+		edited, err := h.editRecipe(id, r.PostFormValue("version"), r.PostFormValue("title"), info)
+		if err == nil {
+			http.Redirect(w, r, recipePattern+url.PathEscape(edited.Title), http.StatusSeeOther)
+			return
+		}
+
+		// keep what was typed so it is not lost
+		tmplData.Edit.Title = r.PostFormValue("title")
+		if editErr, ok := err.(*RecipeEditError); ok {
+			tmplData.Edit.Error = editErr.Reason
+			w.WriteHeader(editErr.Status)
+		} else {
+			h.logger.WithError(err).WithField("recipeTitle", id).Errorln("Failed to edit recipe")
+			tmplData.Edit.Error = "Unable to save the recipe right now: " + err.Error()
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+
+	for _, category := range recipe.ValidCategoriesOrder {
+		tmplData.Edit.Sections = append(tmplData.Edit.Sections, &TemplateEditSection{
+			Name: category,
+			Text: strings.Join(info[category], "\n"),
+		})
+	}
+
+	h.templates.ExecuteTemplate(w, "edit", tmplData)
+}
+
+// recipeCategories is the sorted list of categories recipes are in
+func (h *Handler) recipeCategories() []string {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	seen := make(map[string]bool)
+	categories := []string{}
+	for _, rec := range h.recipes {
+		if rec.Category != "" && !seen[rec.Category] {
+			seen[rec.Category] = true
+			categories = append(categories, rec.Category)
+		}
+	}
+
+	sort.Strings(categories)
+
+	return categories
+}
+
+// addRecipe creates the files for a new recipe and indexes it
+func (h *Handler) addRecipe(rec *recipe.Recipe, image []byte) (*recipe.Recipe, error) {
+	rec.Title = strings.TrimSpace(rec.Title)
+	if recipe.FileName(rec.Title) == "" {
+		return nil, &RecipeEditError{http.StatusBadRequest, "The title must have letters or numbers"}
+	}
+
+	h.edits.Lock()
+	defer h.edits.Unlock()
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if _, exists := h.recipes[rec.Title]; exists {
+		return nil, &RecipeEditError{http.StatusBadRequest, "There is already a recipe named \"" + rec.Title + "\""}
+	}
+
+	created, err := recipe.Create(h.recipePath, rec, image)
+	if err == recipe.ErrRecipeExists {
+		return nil, &RecipeEditError{http.StatusBadRequest, "There is already a recipe folder named \"" + recipe.FileName(rec.Title) + "\""}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	h.recipes[created.Title] = created
+	h.recipeSlice = append(h.recipeSlice, created)
+
+	err = h.idx.Index(created.Title, newRecipeDocument(created))
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.WithFields(log.Fields{
+		"recipeTitle": created.Title,
+		"docx":        created.DocxPath,
+	}).Infoln("Created and indexed")
+
+	return created, nil
+}
+
+// NewRecipe handles the form for creating a recipe
+func (h *Handler) NewRecipe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	tmplData := &TemplateData{
+		PageTitle: "Recipe Card - New recipe",
+		Edit: &TemplateEdit{
+			New:        true,
+			Action:     newRecipePattern,
+			CancelURL:  "/recipes/",
+			Categories: h.recipeCategories(),
+		},
+	}
+
+	info := make(map[string][]string)
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, newRecipeMaxImageSize+1<<20)
+		err := r.ParseMultipartForm(1 << 20)
+		if err != nil && err != http.ErrNotMultipart {
+			tmplData.Edit.Error = "The form is too large, the image must be smaller than 10 MB"
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			h.templates.ExecuteTemplate(w, "edit", tmplData)
+			return
+		}
+
+		for _, category := range recipe.ValidCategoriesOrder {
+			text := strings.Replace(r.FormValue(category), "\r\n", "\n", -1)
+			info[category] = strings.Split(text, "\n")
+		}
+
+		tmplData.Edit.Title = r.FormValue("title")
+		tmplData.Edit.Category = r.FormValue("category")
+		tmplData.Edit.Author = r.FormValue("author")
+		tmplData.Edit.Tags = r.FormValue("tags")
+		tmplData.Edit.Language = r.FormValue("language")
+
+		created, err := h.newRecipeFromForm(r, info)
+		if err == nil {
+			http.Redirect(w, r, recipePattern+url.PathEscape(created.Title), http.StatusSeeOther)
+			return
+		}
+
+		if editErr, ok := err.(*RecipeEditError); ok {
+			tmplData.Edit.Error = editErr.Reason
+			w.WriteHeader(editErr.Status)
+		} else {
+			h.logger.WithError(err).WithField("title", tmplData.Edit.Title).Errorln("Failed to create recipe")
+			tmplData.Edit.Error = "Unable to create the recipe right now: " + err.Error()
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+
+	for _, code := range sortedLanguages() {
+		tmplData.Edit.Languages = append(tmplData.Edit.Languages, &TemplateOption{
+			Value:    code,
+			Label:    languageNames[code],
+			Selected: code == tmplData.Edit.Language,
+		})
+	}
+
+	for _, category := range recipe.ValidCategoriesOrder {
+		tmplData.Edit.Sections = append(tmplData.Edit.Sections, &TemplateEditSection{
+			Name: category,
+			Text: strings.Join(info[category], "\n"),
+		})
+	}
+
+	h.templates.ExecuteTemplate(w, "edit", tmplData)
+}
+
+// newRecipeFromForm creates a recipe from the posted new recipe form
+func (h *Handler) newRecipeFromForm(r *http.Request, info map[string][]string) (*recipe.Recipe, error) {
+	var image []byte
+	file, header, err := r.FormFile("image")
+	if err != nil && err != http.ErrMissingFile {
+		return nil, &RecipeEditError{http.StatusBadRequest, "Unable to read the image"}
+	}
+
+	if err == nil {
+		defer file.Close()
+
+		if header.Size > newRecipeMaxImageSize {
+			return nil, &RecipeEditError{http.StatusRequestEntityTooLarge, "The image must be smaller than 10 MB"}
+		}
+
+		image, err = ioutil.ReadAll(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = jpeg.DecodeConfig(bytes.NewReader(image)); err != nil {
+			return nil, &RecipeEditError{http.StatusBadRequest, "The image must be a JPEG"}
+		}
+	}
+
+	tags := []string{}
+	for _, tag := range strings.FieldsFunc(r.FormValue("tags"), func(r rune) bool {
+		return r == ',' || r == ';'
+	}) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	language := r.FormValue("language")
+	if _, exists := languageNames[language]; language != "" && !exists {
+		return nil, &RecipeEditError{http.StatusBadRequest, "Unknown language \"" + language + "\""}
+	}
+
+	return h.addRecipe(&recipe.Recipe{
+		Title:    r.FormValue("title"),
+		Category: r.FormValue("category"),
+		Author:   r.FormValue("author"),
+		Tags:     tags,
+		Language: language,
+		Info:     info,
+	}, image)
+}
+
+// sortedLanguages are the codes of the recipe languages by name
+func sortedLanguages() []string {
+	codes := make([]string, 0, len(languageNames))
+	for code := range languageNames {
+		codes = append(codes, code)
+	}
+
+	sort.Slice(codes, func(i, j int) bool {
+		return languageNames[codes[i]] < languageNames[codes[j]]
+	})
+
+	return codes
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+func TestEditRecipe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-edit")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	handler := newTestHandler(t)
+	handler.recipePath = dir
+	rec, err := handler.addRecipe(&recipe.Recipe{
+		Title:    "Toast",
+		Category: "Breakfast",
+		Info:     map[string][]string{"ingredients": {"1 slice bread"}},
+	}, nil)
+	if err != nil {
+		t.Fatal("Failed to add recipe", err)
+	}
+
+	version, err := rec.Version()
+	if err != nil {
+		t.Fatal("Failed to get version", err)
+	}
+
+	// scans and readers may come in while the edit is parsed and indexed
+	headers := scanHeaders(t, "front.png")
+	wait := sync.WaitGroup{}
+	wait.Add(2)
+	go func() {
+		defer wait.Done()
+		handler.addScans("Toast", headers)
+	}()
+
+	go func() {
+		defer wait.Done()
+		for i := 0; i < 100; i++ {
+			handler.getRecipe("Toast")
+			handler.recipeCount()
+		}
+	}()
+
+	edited, err := handler.editRecipe("Toast", version, "Cinnamon Toast", map[string][]string{"ingredients": {"1 slice bread", "1 tsp cinnamon"}})
+	wait.Wait()
+	if err != nil {
+		t.Fatal("Failed to edit recipe", err)
+	}
+
+	if current, _ := handler.getRecipe("Cinnamon Toast"); current != edited {
+		t.Errorf("Expected the edited recipe, got %v", current)
+	}
+
+	if _, exists := handler.getRecipe("Toast"); exists {
+		t.Error("Expected the old title to be gone")
+	}
+
+	results, err := handler.search("cinnamon", 1, 10, "")
+	if err != nil {
+		t.Fatal("Failed to search", err)
+	}
+
+	if results.Total != 1 || results.Hits[0].ID != "Cinnamon Toast" {
+		t.Errorf("Expected the edit to be indexed, got %v", results.Hits)
+	}
+
+	_, err = handler.editRecipe("Cinnamon Toast", version, "Toast", nil)
+	if editErr, ok := err.(*RecipeEditError); !ok || editErr.Status != http.StatusConflict {
+		t.Errorf("Expected a conflict editing an old version, got %v", err)
+	}
+
+	_, err = handler.editRecipe("Cinnamon Toast", version, "Banana Bread", nil)
+	if editErr, ok := err.(*RecipeEditError); !ok || editErr.Status != http.StatusBadRequest {
+		t.Errorf("Expected an error taking the title of another recipe, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Chapter is a page of a book
+type Chapter struct {
+	Title string
+	// heading the chapter is listed under in the table of contents, such as a category
+	Group string
+	// XHTML of the inside of the body element
+	Body string
+}
+
+// Image is a picture chapters show with an img element whose src is ImagePath(Name)
+type Image struct {
+	Name      string
+	MediaType string
+	Data      []byte
+}
+
+// Book is an EPUB 3 book
+type Book struct {
+	Title    string
+	Author   string
+	Language string
+	// unique identifier of the book, such as a urn:uuid
+	Identifier string
+	// the time the book was written is used when it is zero
+	Modified time.Time
+	Chapters []*Chapter
+	Images   []*Image
+	// CSS shared by every chapter
+	Style string
+}
+
+// ChapterPath is the path of a chapter relative to the other chapters, for links between them
+func ChapterPath(index int) string {
+	return fmt.Sprintf("chapter%03d.xhtml", index+1)
+}
+
+// ImagePath is the path of an image relative to the chapters
+func ImagePath(name string) string {
+	return "images/" + name
+}
+
+// Escape escapes text for use in the XHTML of a chapter
+func Escape(text string) string {
+	buf := new(bytes.Buffer)
+	xml.EscapeText(buf, []byte(text))
+	return buf.String()
+}
+
+// Write writes the book as an EPUB
+func (b *Book) Write(writer io.Writer) error {
+	zipWriter := zip.NewWriter(writer)
+
+	// the mimetype must be first and must not be compressed
+	mimetype, err := zipWriter.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(mimetype, "application/epub+zip")
+	if err != nil {
+		return err
+	}
+
+	type file struct {
+		name string
+		data string
+	}
+
+	files := []file{
+		{"META-INF/container.xml", containerXML},
+		{"OEBPS/content.opf", b.packageXML()},
+		{"OEBPS/nav.xhtml", b.page("Contents", b.navXHTML())},
+		{"OEBPS/style.css", b.Style},
+	}
+
+	for i, chapter := range b.Chapters {
+		files = append(files, file{"OEBPS/" + ChapterPath(i), b.page(chapter.Title, chapter.Body)})
+	}
+
+	for _, image := range b.Images {
+		files = append(files, file{"OEBPS/" + ImagePath(image.Name), string(image.Data)})
+	}
+
+	for _, f := range files {
+		fileWriter, err := zipWriter.Create(f.name)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(fileWriter, f.data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+// language is the language of the book, English when it is not set
+func (b *Book) language() string {
+	if b.Language == "" {
+		return "en"
+	}
+
+	return b.Language
+}
+
+// page wraps the body of a chapter in an XHTML document
+func (b *Book) page(title, body string) string {
+	return xml.Header + "<!DOCTYPE html>\n" +
+		`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="` + Escape(b.language()) + `">` +
+		"<head><title>" + Escape(title) + `</title><link rel="stylesheet" type="text/css" href="style.css"/></head>` +
+		"<body>" + body + "</body></html>\n"
+}
+
+// navXHTML is the table of contents, consecutive chapters of the same group are nested under it
+func (b *Book) navXHTML() string {
+	nav := strings.Builder{}
+	nav.WriteString(`<nav epub:type="toc" id="toc"><h1>Contents</h1><ol>`)
+	group := ""
+	for i, chapter := range b.Chapters {
+		if chapter.Group != group {
+			if group != "" {
+				nav.WriteString("</ol></li>")
+			}
+
+			group = chapter.Group
+			if group != "" {
+				nav.WriteString("<li><span>" + Escape(group) + "</span><ol>")
+			}
+		}
+
+		nav.WriteString(`<li><a href="` + ChapterPath(i) + `">` + Escape(chapter.Title) + "</a></li>")
+	}
+
+	if group != "" {
+		nav.WriteString("</ol></li>")
+	}
+
+	nav.WriteString("</ol></nav>")
+	return nav.String()
+}
+
+// packageXML lists the metadata, files and reading order of the book
+func (b *Book) packageXML() string {
+	modified := b.Modified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	opf := strings.Builder{}
+	opf.WriteString(xml.Header)
+	opf.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid">`)
+	opf.WriteString(`<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">`)
+	opf.WriteString(`<dc:identifier id="bookid">` + Escape(b.Identifier) + "</dc:identifier>")
+	opf.WriteString("<dc:title>" + Escape(b.Title) + "</dc:title>")
+	opf.WriteString("<dc:language>" + Escape(b.language()) + "</dc:language>")
+	if b.Author != "" {
+		opf.WriteString("<dc:creator>" + Escape(b.Author) + "</dc:creator>")
+	}
+
+	opf.WriteString(`<meta property="dcterms:modified">` + modified.UTC().Format("2006-01-02T15:04:05Z") + "</meta>")
+	opf.WriteString("</metadata><manifest>")
+	opf.WriteString(`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>`)
+	opf.WriteString(`<item id="style" href="style.css" media-type="text/css"/>`)
+	for i := range b.Chapters {
+		fmt.Fprintf(&opf, `<item id="chapter%d" href="%s" media-type="application/xhtml+xml"/>`, i+1, ChapterPath(i))
+	}
+
+	for i, image := range b.Images {
+		fmt.Fprintf(&opf, `<item id="image%d" href="%s" media-type="%s"/>`, i+1, Escape(ImagePath(image.Name)), Escape(image.MediaType))
+	}
+
+	opf.WriteString(`</manifest><spine><itemref idref="nav"/>`)
+	for i := range b.Chapters {
+		fmt.Fprintf(&opf, `<itemref idref="chapter%d"/>`, i+1)
+	}
+
+	opf.WriteString("</spine></package>\n")
+	return opf.String()
+}
+
+const containerXML = xml.Header + `<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">` +
+	`<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>` +
+	"</container>\n"
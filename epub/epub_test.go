@@ -0,0 +1,112 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	book := &Book{
+		Title:      "Family <Cookbook>",
+		Author:     "Grandma",
+		Identifier: "urn:uuid:0",
+		Modified:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Chapters: []*Chapter{
+			{Title: "Banana Bread", Group: "Baking", Body: `<h1>Banana Bread</h1><img src="` + ImagePath("bread.jpg") + `" alt=""/>`},
+			{Title: "Muffins", Group: "Baking", Body: "<h1>Muffins</h1>"},
+			{Title: "Index", Body: `<h1>Index</h1><a href="` + ChapterPath(0) + `">` + Escape("Banana & Bread") + "</a>"},
+		},
+		Images: []*Image{{Name: "bread.jpg", MediaType: "image/jpeg", Data: []byte("jpeg")}},
+		Style:  "h1 { color: red; }",
+	}
+
+	buf := new(bytes.Buffer)
+	err := book.Write(buf)
+	if err != nil {
+		t.Fatal("Failed to write book", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal("Book is not a zip", err)
+	}
+
+	if first := reader.File[0]; first.Name != "mimetype" || first.Method != zip.Store {
+		t.Errorf("Expected an uncompressed mimetype first, got %s", first.Name)
+	}
+
+	files := make(map[string]string)
+	for _, file := range reader.File {
+		fileReader, err := file.Open()
+		if err != nil {
+			t.Fatal("Failed to open", file.Name, err)
+		}
+
+		data, err := ioutil.ReadAll(fileReader)
+		fileReader.Close()
+		if err != nil {
+			t.Fatal("Failed to read", file.Name, err)
+		}
+
+		files[file.Name] = string(data)
+	}
+
+	expected := []string{
+		"META-INF/container.xml",
+		"OEBPS/content.opf",
+		"OEBPS/nav.xhtml",
+		"OEBPS/style.css",
+		"OEBPS/chapter001.xhtml",
+		"OEBPS/chapter002.xhtml",
+		"OEBPS/chapter003.xhtml",
+		"OEBPS/images/bread.jpg",
+	}
+
+	for _, name := range expected {
+		data, exists := files[name]
+		if !exists {
+			t.Errorf("Expected %s in the book", name)
+			continue
+		}
+
+		// every XML file must be well formed
+		if strings.HasSuffix(name, ".xml") || strings.HasSuffix(name, ".opf") || strings.HasSuffix(name, ".xhtml") {
+			decoder := xml.NewDecoder(strings.NewReader(data))
+			for {
+				_, err = decoder.Token()
+				if err == io.EOF {
+					break
+				}
+
+				if err != nil {
+					t.Errorf("%s is not well formed: %s", name, err)
+					break
+				}
+			}
+		}
+	}
+
+	opf := files["OEBPS/content.opf"]
+	for _, text := range []string{
+		"<dc:title>Family &lt;Cookbook&gt;</dc:title>",
+		`<meta property="dcterms:modified">2020-01-02T03:04:05Z</meta>`,
+		`href="images/bread.jpg" media-type="image/jpeg"`,
+		`<itemref idref="chapter3"/>`,
+	} {
+		if !strings.Contains(opf, text) {
+			t.Errorf("Expected the package to contain %s", text)
+		}
+	}
+
+	nav := files["OEBPS/nav.xhtml"]
+	grouped := `<li><span>Baking</span><ol><li><a href="chapter001.xhtml">Banana Bread</a></li><li><a href="chapter002.xhtml">Muffins</a></li></ol></li><li><a href="chapter003.xhtml">Index</a></li>`
+	if !strings.Contains(nav, grouped) {
+		t.Errorf("Expected chapters grouped in the table of contents, got %s", nav)
+	}
+}
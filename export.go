@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/tblyler/recipe-card/pdf"
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+const (
+	// recipePDFSuffix is added to a recipe page URL for the recipe as a PDF
+	recipePDFSuffix = ".pdf"
+
+	// exportImageWidth is the width images are resized to before they are put in a PDF
+	exportImageWidth = 1200
+)
+
+// recipePDFURL is the relative URL of the recipe title as a PDF
+func recipePDFURL(title string) string {
+	return recipePattern + url.PathEscape(title) + recipePDFSuffix
+}
+
+// writeRecipe lays out a recipe from where the document is up to
+// scans are added after the recipe, each on its own page, scans that can not be read are left out
+func writeRecipe(document *pdf.Document, rec *recipe.Recipe, scans bool) error {
+	document.Paragraph(rec.Title, pdf.Bold, 22)
+	if rec.Author != "" {
+		document.Paragraph(rec.Author, pdf.Regular, 11)
+	}
+
+	document.Space(12)
+
+	if len(rec.Image) > 0 {
+		// images are turned upright and made small enough to keep the PDF small
+		image, err := resizeToJPEG(rec.Image, exportImageWidth)
+		if err != nil {
+			return fmt.Errorf("stock image of %s: %s", rec.Title, err)
+		}
+
+		err = document.Image(image, pdf.PageWidth/2, pdf.PageHeight/3)
+		if err != nil {
+			return fmt.Errorf("stock image of %s: %s", rec.Title, err)
+		}
+
+		document.Space(12)
+	}
+
+	for _, category := range recipe.ValidCategoriesOrder {
+		lines, exists := rec.Info[category]
+		if !exists {
+			continue
+		}
+
+		// keep headings with at least a couple of their lines
+		if document.Remaining() < 60 {
+			document.NewPage()
+		}
+
+		document.Paragraph(strings.Title(category), pdf.Bold, 14)
+		for _, line := range lines {
+			document.Indented(line, pdf.Regular, 11, 12)
+		}
+
+		document.Space(10)
+	}
+
+	if !scans {
+		return nil
+	}
+
+	for _, scanPath := range rec.ScanPaths {
+		data, err := ioutil.ReadFile(scanPath)
+		if err != nil {
+			continue
+		}
+
+		image, err := resizeToJPEG(data, exportImageWidth)
+		if err != nil {
+			continue
+		}
+
+		document.NewPage()
+		err = document.Image(image, pdf.PageWidth, pdf.PageHeight)
+		if err != nil {
+			return fmt.Errorf("%s: %s", scanPath, err)
+		}
+	}
+
+	return nil
+}
+
+// WriteRecipePDF writes the recipe as a PDF, with its scans if scans is true
+func WriteRecipePDF(writer io.Writer, rec *recipe.Recipe, scans bool) error {
+	document := pdf.NewDocument(pdf.Info{
+		Title:    rec.Title,
+		Author:   rec.Author,
+		Subject:  rec.Category,
+		Keywords: strings.Join(rec.Tags, ", "),
+		Created:  rec.Modified,
+	})
+
+	err := writeRecipe(document, rec, scans)
+	if err != nil {
+		return err
+	}
+
+	_, err = document.WriteTo(writer)
+	return err
+}
+
+// RecipePDF handles downloading the recipe titled id as a PDF
+// a scans parameter, such as ?scans=1, adds the scans of the card
+func (h *Handler) RecipePDF(w http.ResponseWriter, r *http.Request, id string) {
+	rec, exists := h.getRecipe(id)
+	if !exists {
+		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+		return
+	}
+
+	// lay out the whole PDF first, so a failure is not sent as half a file
+	buf := new(bytes.Buffer)
+	err := WriteRecipePDF(buf, rec, r.URL.Query().Get("scans") != "")
+	if err != nil {
+		h.logger.WithError(err).WithField("recipeTitle", rec.Title).Errorln("Failed to create PDF")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", recipe.FileName(rec.Title)+".pdf"))
+	buf.WriteTo(w)
+}
+
+// exportRecipePDF writes the recipe titled title from recipePath to the PDF at output, for the command line
+func exportRecipePDF(recipePath, title, output string, scans bool) error {
+	recipes, err := recipe.RecipesFromPath(recipePath)
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range recipes {
+		if rec.Title != title {
+			continue
+		}
+
+		if output == "" {
+			output = recipe.FileName(rec.Title) + ".pdf"
+		}
+
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+
+		err = WriteRecipePDF(file, rec, scans)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			os.Remove(output)
+		}
+
+		return err
+	}
+
+	return fmt.Errorf("there is no recipe titled %q in %s", title, recipePath)
+}
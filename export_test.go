@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecipePDF(t *testing.T) {
+	handler := newTestHandler(t)
+
+	recorder := httptest.NewRecorder()
+	handler.Recipe(recorder, httptest.NewRequest(http.MethodGet, "/recipe/Banana%20Bread.pdf", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", recorder.Code)
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/pdf" {
+		t.Errorf("Expected a PDF, got \"%s\"", contentType)
+	}
+
+	data := recorder.Body.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.Contains(data, []byte("/Title (Banana Bread)")) {
+		t.Error("Expected a PDF of Banana Bread")
+	}
+
+	// the scan of Banana Bread does not exist, so it is left out
+	recorder = httptest.NewRecorder()
+	handler.Recipe(recorder, httptest.NewRequest(http.MethodGet, "/recipe/Banana%20Bread.pdf?scans=1", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with missing scans, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.Recipe(recorder, httptest.NewRequest(http.MethodGet, "/recipe/Waffles.pdf", nil))
+	if recorder.Code != http.StatusTemporaryRedirect {
+		t.Errorf("Expected a redirect for a missing recipe, got %d", recorder.Code)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"html"
 	"html/template"
@@ -14,10 +15,11 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/blevesearch/bleve"
-	blevemapping "github.com/blevesearch/bleve/mapping"
 	log "github.com/sirupsen/logrus"
 	"github.com/tblyler/recipe-card/recipe"
 )
@@ -27,14 +29,21 @@ const (
 	stockImagePatten = "/stock-images/"
 	recipePattern    = "/recipe/"
 	docxPattern      = "/docx/"
+	// attachmentPattern serves documents in recipe folders, such as scanned PDFs
+	attachmentPattern = "/attachments/"
 )
 
 // Handler contains functions for http handlerfunc
 type Handler struct {
-	recipePath  string
+	recipePath string
+	// serializes changes to recipes, so edits can parse and index without holding lock
+	edits sync.Mutex
+	// guards recipes and recipeSlice, which change when a recipe is edited
+	lock        sync.RWMutex
 	recipes     map[string]*recipe.Recipe
 	recipeSlice []*recipe.Recipe
 	idx         bleve.Index
+	thumbnails  *thumbnailCache
 	templates   *template.Template
 	logger      *log.Logger
 }
@@ -102,7 +111,8 @@ func SaveItemIndex(itemIndex map[string][]byte, path string) error {
 }
 
 // NewHandler creates a new instance to handle HTTP requests
-func NewHandler(recipePath string, indexPath string, logger *log.Logger) (*Handler, error) {
+// synonymsPath is an optional file of extra cooking synonyms for searching
+func NewHandler(recipePath string, indexPath string, synonymsPath string, logger *log.Logger) (*Handler, error) {
 	if logger == nil {
 		logger = log.New()
 		logger.Out = ioutil.Discard
@@ -129,12 +139,33 @@ func NewHandler(recipePath string, indexPath string, logger *log.Logger) (*Handl
 		os.MkdirAll(indexPath, 0755)
 	}
 
+	// scans that have not changed are not decoded again to hash them
+	scanHashesPath := ""
+	if indexPath != "" {
+		scanHashesPath = filepath.Join(indexPath, "scanhashes.json")
+		err = recipe.LoadScanHashes(scanHashesPath)
+		if err != nil && !os.IsNotExist(err) {
+			logger.WithError(err).WithField("scanHashesPath", scanHashesPath).Warnln(
+				"Failed to load scan hashes",
+			)
+		}
+	}
+
 	logger.WithField("recipePath", recipePath).Infoln("Getting recipes from path")
 	recipeSlice, err := recipe.RecipesFromPath(recipePath)
 	if err != nil {
 		return nil, err
 	}
 
+	if scanHashesPath != "" {
+		err = recipe.SaveScanHashes(scanHashesPath)
+		if err != nil {
+			logger.WithError(err).WithField("scanHashesPath", scanHashesPath).Warnln(
+				"Failed to save scan hashes",
+			)
+		}
+	}
+
 	logger.Infof("Found %d recipes", len(recipeSlice))
 
 	handler := new(Handler)
@@ -144,27 +175,55 @@ func NewHandler(recipePath string, indexPath string, logger *log.Logger) (*Handl
 	handler.recipes = make(map[string]*recipe.Recipe)
 	bleveIndexPath := ""
 	itemIndexPath := ""
-	// this improves indexing performance a shit ton
-	// I don't think it stores the document data, just analysis data
-	// could be wrong, documentation is sparse for it
-	// functionality seems the same for here though
-	blevemapping.StoreDynamic = false
+	// an index created with an older mapping must be rebuilt from scratch
+	staleIndex := false
+
+	logger.WithField("synonymsPath", synonymsPath).Debugln("Loading synonyms")
+	synonyms, err := LoadSynonyms(synonymsPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load synonyms: %s", err.Error())
+	}
+
+	version := indexVersionFor(synonyms)
+	indexMapping, err := NewIndexMapping(synonyms)
+	if err != nil {
+		return nil, fmt.Errorf("Bleve mapping: %s", err.Error())
+	}
 
 	if indexPath == "" {
 		logger.Info("Creating memory mapped search index")
-		handler.idx, err = bleve.NewMemOnly(bleve.NewIndexMapping())
+		handler.idx, err = bleve.NewMemOnly(indexMapping)
 	} else {
 		itemIndexPath = filepath.Join(indexPath, "item.idx")
 		bleveIndexPath = filepath.Join(indexPath, "bleve")
 		logger.WithField("bleveIndexPath", bleveIndexPath).Infoln("Trying to open index path")
 
 		handler.idx, err = bleve.Open(bleveIndexPath)
-		if err != nil {
+		if err == nil {
+			oldVersion, _ := handler.idx.GetInternal([]byte(indexVersionKey))
+			if string(oldVersion) != version {
+				logger.WithFields(log.Fields{
+					"bleveIndexPath": bleveIndexPath,
+					"version":        string(oldVersion),
+					"newVersion":     version,
+				}).Warnln("Index version changed, recreating it")
+
+				handler.idx.Close()
+				err = os.RemoveAll(bleveIndexPath)
+				if err != nil {
+					return nil, fmt.Errorf("Bleve remove: %s", err.Error())
+				}
+
+				staleIndex = true
+				handler.idx, err = bleve.New(bleveIndexPath, indexMapping)
+			}
+		} else {
 			logger.WithError(err).WithField("bleveIndexPath", bleveIndexPath).Warnln(
 				"Failed to open index path, trying to recreate it",
 			)
 
-			handler.idx, err = bleve.New(bleveIndexPath, bleve.NewIndexMapping())
+			staleIndex = true
+			handler.idx, err = bleve.New(bleveIndexPath, indexMapping)
 		}
 	}
 
@@ -172,9 +231,14 @@ func NewHandler(recipePath string, indexPath string, logger *log.Logger) (*Handl
 		return nil, fmt.Errorf("Bleve open: %s", err.Error())
 	}
 
+	err = handler.idx.SetInternal([]byte(indexVersionKey), []byte(version))
+	if err != nil {
+		return nil, fmt.Errorf("Bleve set version: %s", err.Error())
+	}
+
 	var itemIndex map[string][]byte
 
-	if itemIndexPath != "" {
+	if itemIndexPath != "" && !staleIndex {
 		logger.WithField("itemIndexPath", itemIndexPath).Debugln("Trying to open previous item index")
 		itemIndex, err = GetItemIndex(itemIndexPath)
 		if err != nil {
@@ -210,6 +274,16 @@ func NewHandler(recipePath string, indexPath string, logger *log.Logger) (*Handl
 		logger.WithField("recipeTitle", recip.Title).Debugln("Hashing data")
 		hasher := sha256.New()
 		io.WriteString(hasher, recip.Title)
+		for _, tag := range recip.Tags {
+			io.WriteString(hasher, tag)
+		}
+
+		io.WriteString(hasher, recip.Category)
+		io.WriteString(hasher, recip.Author)
+		io.WriteString(hasher, recip.Language)
+		io.WriteString(hasher, recip.Created.String())
+		io.WriteString(hasher, recip.Modified.String())
+
 		for _, order := range recipe.ValidCategoriesOrder {
 			if info, exists := recip.Info[order]; exists {
 				for _, line := range info {
@@ -236,7 +310,7 @@ func NewHandler(recipePath string, indexPath string, logger *log.Logger) (*Handl
 			}
 
 			itemIndex[recip.Title] = sha256sum
-			err = handler.idx.Index(recip.Title, recip)
+			err = handler.idx.Index(recip.Title, newRecipeDocument(recip))
 			if err != nil {
 				return nil, fmt.Errorf("Index fail: %s", err.Error())
 			}
@@ -262,6 +336,16 @@ func NewHandler(recipePath string, indexPath string, logger *log.Logger) (*Handl
 		logger.Infoln("Updated index data")
 	}
 
+	thumbnailPath := ""
+	if indexPath != "" {
+		thumbnailPath = filepath.Join(indexPath, thumbnailDirName)
+	}
+
+	handler.thumbnails, err = newThumbnailCache(thumbnailPath)
+	if err != nil {
+		return nil, fmt.Errorf("Thumbnail cache: %s", err.Error())
+	}
+
 	handler.templates, err = NewTemplate(logger)
 	if err != nil {
 		return nil, err
@@ -272,25 +356,87 @@ func NewHandler(recipePath string, indexPath string, logger *log.Logger) (*Handl
 
 // Close handler and free up memory
 func (h *Handler) Close() error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
 	h.recipes = nil
 	return h.idx.Close()
 }
 
+// replaceRecipe swaps old for rec, which may have a new title
+// the caller must hold the write lock
+func (h *Handler) replaceRecipe(old, rec *recipe.Recipe) {
+	if h.recipes[old.Title] == old {
+		delete(h.recipes, old.Title)
+	}
+
+	h.recipes[rec.Title] = rec
+	for i, sliceRecipe := range h.recipeSlice {
+		if sliceRecipe == old {
+			h.recipeSlice[i] = rec
+		}
+	}
+}
+
+// getRecipe gets the recipe with the title id
+func (h *Handler) getRecipe(id string) (*recipe.Recipe, bool) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	rec, exists := h.recipes[id]
+	return rec, exists
+}
+
+// recipeCount is the number of recipes being served
+func (h *Handler) recipeCount() int {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return len(h.recipes)
+}
+
 // GetHandlerFuncs in a pattern->func map
 func (h *Handler) GetHandlerFuncs() map[string]http.HandlerFunc {
 	return map[string]http.HandlerFunc{
-		"/":              h.Index,
-		"/search/":       h.Search,
-		"/recipes/":      h.Recipes,
-		recipePattern:    h.Recipe,
-		"/css/mini.css":  h.MiniCSS,
-		"/css/main.css":  h.MainCSS,
-		imagePattern:     h.Images,
-		stockImagePatten: h.StockImages,
-		docxPattern:      h.Docx,
+		"/":                 h.Index,
+		searchPattern:       h.Search,
+		"/recipes/":         h.Recipes,
+		newRecipePattern:    h.NewRecipe,
+		importRecipePattern: h.ImportRecipe,
+		recipePattern:       h.Recipe,
+		"/css/mini.css":     h.MiniCSS,
+		"/css/main.css":     h.MainCSS,
+		imagePattern:        h.Images,
+		stockImagePatten:    h.StockImages,
+		docxPattern:         h.Docx,
+		attachmentPattern:   h.Attachments,
+		duplicatesPattern:   h.Duplicates,
+		cookbookPattern:     h.Cookbook,
+		pantryPattern:       h.Pantry,
+		apiPantryPattern:    h.APIPantry,
+		apiSuggestPattern:   h.APISuggest,
+		apiV1Pattern:        h.APIV1,
+		apiOpenAPIPattern:   h.APIOpenAPI,
 	}
 }
 
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	encoder.Encode(v)
+}
+
+// writeJSONError writes a JSON error response with the given status code
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{
+		"error": message,
+	})
+}
+
 // MainCSS outputs the main css file information
 func (h *Handler) MainCSS(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/css")
@@ -318,38 +464,76 @@ func (h *Handler) Index(w http.ResponseWriter, r *http.Request) {
 
 // Search handles search request
 func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	// old bookmarks and forms POST the search, send them to the shareable URL
+	if r.Method == http.MethodPost {
+		search := strings.TrimSpace(r.PostFormValue("search"))
+		if search == "" {
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+
+		http.Redirect(w, r, searchURL(search, 1, ""), http.StatusSeeOther)
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/html")
-	search := strings.TrimSpace(r.PostFormValue("search"))
+	values := r.URL.Query()
+	search := strings.TrimSpace(values.Get("q"))
 	if search == "" {
 		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
 		return
 	}
 
+	page := browsePage(values, searchPageSize)
+	language := values.Get("lang")
 	tmplData := &TemplateData{
-		PageTitle:   "Recipe Card - Search",
+		PageTitle:   "Recipe Card - Search - " + search,
 		SearchValue: search,
+		SearchURL:   searchURL(search, page, language),
 	}
 
-	searchResults, _ := h.idx.Search(bleve.NewSearchRequest(bleve.NewMatchQuery(
-		search,
-	)))
+	searchResults, err := h.search(search, page, searchPageSize, language)
+	if err != nil {
+		if _, ok := err.(*SearchSyntaxError); ok {
+			tmplData.SearchError = err.Error()
+		} else {
+			h.logger.WithError(err).WithField("search", search).Errorln("Failed to search")
+			tmplData.SearchError = "Unable to search right now: " + err.Error()
+		}
 
-	// try a fuzzy search if matchquery fails
-	if searchResults.Hits.Len() == 0 {
-		searchResults, _ = h.idx.Search(bleve.NewSearchRequest(bleve.NewFuzzyQuery(
-			search,
-		)))
+		h.templates.ExecuteTemplate(w, "search", tmplData)
+		return
 	}
 
 	for _, hit := range searchResults.Hits {
-		recipe := h.recipes[hit.ID]
+		recipe, exists := h.getRecipe(hit.ID)
+		if !exists {
+			continue
+		}
+
+		tmplRecipe := h.recipeToTemplateRecipe(recipe)
+		tmplRecipe.Score = hit.Score
+		tmplRecipe.Fragments = templateFragments(hit)
 
 		tmplData.Recipes = append(
 			tmplData.Recipes,
-			h.recipeToTemplateRecipe(recipe),
+			tmplRecipe,
 		)
 	}
 
+	tmplData.Total = searchResults.Total
+	if languageFacet := searchLanguageFacet(search, language, searchResults.Facets); languageFacet != nil {
+		tmplData.Facets = append(tmplData.Facets, languageFacet)
+	}
+
+	if page > 1 {
+		tmplData.PrevURL = searchURL(search, page-1, language)
+	}
+
+	if uint64(page*searchPageSize) < searchResults.Total {
+		tmplData.NextURL = searchURL(search, page+1, language)
+	}
+
 	h.templates.ExecuteTemplate(w, "search", tmplData)
 }
 
@@ -357,55 +541,142 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) Recipes(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 
+	values := r.URL.Query()
 	tmplData := &TemplateData{
 		PageTitle: "Recipe Card - Recipes",
 	}
 
-	for _, recipe := range h.recipeSlice {
+	searchResults, err := h.idx.Search(newBrowseRequest(values, browsePageSize))
+	if err != nil {
+		h.logger.WithError(err).WithField("query", values.Encode()).Errorln("Failed to browse recipes")
+		tmplData.SearchError = "Unable to list recipes right now: " + err.Error()
+		h.templates.ExecuteTemplate(w, "recipes", tmplData)
+		return
+	}
+
+	for _, hit := range searchResults.Hits {
+		recipe, exists := h.getRecipe(hit.ID)
+		if !exists {
+			continue
+		}
+
 		tmplData.Recipes = append(
 			tmplData.Recipes,
 			h.recipeToTemplateRecipe(recipe),
 		)
 	}
 
+	tmplData.Facets = templateFacets(values, searchResults.Facets)
+	tmplData.Total = searchResults.Total
+
+	currentSort := browseSortOption(values.Get("sort"))
+	for _, option := range browseSortOptions {
+		tmplData.SortOptions = append(tmplData.SortOptions, &TemplateLink{
+			Label:  option.Label,
+			URL:    browseURL(values, "sort", option.Param),
+			Active: option.Param == currentSort.Param,
+		})
+	}
+
+	page := browsePage(values, browsePageSize)
+	if page > 1 {
+		tmplData.PrevURL = browseURL(values, "page", strconv.Itoa(page-1))
+	}
+
+	if uint64(page*browsePageSize) < searchResults.Total {
+		tmplData.NextURL = browseURL(values, "page", strconv.Itoa(page+1))
+	}
+
 	h.templates.ExecuteTemplate(w, "recipes", tmplData)
 }
 
 // Recipe handles a single recipe page
 func (h *Handler) Recipe(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/html")
-
 	id := strings.TrimPrefix(r.URL.Path, recipePattern)
+	if strings.HasSuffix(id, recipeEditSuffix) {
+		h.EditRecipe(w, r, strings.TrimSuffix(id, recipeEditSuffix))
+		return
+	}
 
-	if recipe, exists := h.recipes[id]; exists {
-		tmplData := &TemplateData{
-			PageTitle: "Recipe Card - " + id,
-		}
+	if strings.HasSuffix(id, recipeScansSuffix) {
+		h.UploadScans(w, r, strings.TrimSuffix(id, recipeScansSuffix))
+		return
+	}
 
-		tmplData.Recipes = append(
-			tmplData.Recipes,
-			h.recipeToTemplateRecipe(recipe),
-		)
+	if strings.HasSuffix(id, recipePrintSuffix) {
+		h.PrintRecipe(w, r, strings.TrimSuffix(id, recipePrintSuffix))
+		return
+	}
+
+	// a recipe could have a title ending in .pdf
+	if _, exists := h.getRecipe(strings.TrimSuffix(id, recipePDFSuffix)); exists && strings.HasSuffix(id, recipePDFSuffix) {
+		h.RecipePDF(w, r, strings.TrimSuffix(id, recipePDFSuffix))
+		return
+	}
 
-		h.templates.ExecuteTemplate(w, "recipe", tmplData)
+	if recipe, exists := h.getRecipe(id); exists {
+		h.recipePage(w, recipe, http.StatusOK, "")
 		return
 	}
 
 	http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
 }
 
+// recipePage writes the page of a recipe with an optional error for the scan upload
+func (h *Handler) recipePage(w http.ResponseWriter, rec *recipe.Recipe, status int, uploadError string) {
+	w.Header().Set("Content-Type", "text/html")
+
+	tmplData := &TemplateData{
+		PageTitle:   "Recipe Card - " + rec.Title,
+		UploadError: uploadError,
+	}
+
+	tmplRecipe := h.recipeToTemplateRecipe(rec)
+	jsonLD, err := recipeJSONLDScript(rec, tmplRecipe)
+	if err != nil {
+		h.logger.WithError(err).WithField("recipeTitle", rec.Title).Warnln("Failed to describe recipe as JSON-LD")
+	}
+
+	tmplRecipe.JSONLD = jsonLD
+	tmplData.Recipes = append(tmplData.Recipes, tmplRecipe)
+
+	similar, err := h.similarRecipes(rec, similarRecipeCount)
+	if err != nil {
+		h.logger.WithError(err).WithField("recipeTitle", rec.Title).Warnln("Failed to find similar recipes")
+	}
+
+	for _, similarRecipe := range similar {
+		tmplData.SimilarRecipes = append(
+			tmplData.SimilarRecipes,
+			h.recipeToTemplateRecipe(similarRecipe),
+		)
+	}
+
+	w.WriteHeader(status)
+	h.templates.ExecuteTemplate(w, "recipe", tmplData)
+}
+
 // StockImages handles all stock image requests
+// a width parameter, such as ?w=400, gets a smaller version of the image
 func (h *Handler) StockImages(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "image/jpeg")
-
 	id := strings.TrimPrefix(strings.TrimSuffix(r.URL.Path, ".jpg"), stockImagePatten)
-	if recipe, exists := h.recipes[id]; exists {
-		w.Write(recipe.Image)
+	rec, exists := h.getRecipe(id)
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	rawWidth := r.URL.Query().Get("w")
+	if rawWidth != "" || recipe.JPEGOrientation(bytes.NewReader(rec.Image)) != 1 {
+		sum := sha256.Sum256(rec.Image)
+		h.thumbnail(w, rawWidth, "stock:"+hex.EncodeToString(sum[:]), func() ([]byte, error) {
+			return rec.Image, nil
+		})
 		return
 	}
 
-	w.WriteHeader(http.StatusNotFound)
-	return
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Write(rec.Image)
 }
 
 // Docx handles all docx download requests
@@ -429,16 +700,51 @@ func (h *Handler) Docx(w http.ResponseWriter, r *http.Request) {
 	io.Copy(w, file)
 }
 
+// Attachments handles downloading the PDFs in recipe folders
+func (h *Handler) Attachments(w http.ResponseWriter, r *http.Request) {
+	if !recipe.IsAttachment(r.URL.Path) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	path := h.urlToPath(r.URL.Path, attachmentPattern)
+	file, err := os.Open(path)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	// only serve files that really are PDFs
+	header := make([]byte, 5)
+	if _, err = io.ReadFull(file, header); err != nil || string(header) != "%PDF-" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	// ServeContent handles range requests, which PDF viewers use for large files
+	http.ServeContent(w, r, stat.Name(), stat.ModTime(), file)
+}
+
 // Images handles all image requests
+// a width parameter, such as ?w=400, gets a smaller version of the image
+// images browsers can not show, such as TIFF, are converted to JPEG
 func (h *Handler) Images(w http.ResponseWriter, r *http.Request) {
-	lowerPath := strings.ToLower(r.URL.Path)
-
-	if !strings.HasSuffix(lowerPath, "jpg") && !strings.HasSuffix(lowerPath, "jpeg") {
+	if !recipe.IsScan(r.URL.Path) {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 
-	file, err := os.Open(h.urlToPath(r.URL.Path, imagePattern))
+	path := h.urlToPath(r.URL.Path, imagePattern)
+	file, err := os.Open(path)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		return
@@ -446,10 +752,68 @@ func (h *Handler) Images(w http.ResponseWriter, r *http.Request) {
 
 	defer file.Close()
 
-	w.Header().Set("Content-Type", "image/jpeg")
+	// trust the bytes rather than the extension
+	header := make([]byte, recipe.ExifHeaderSize)
+	read, _ := io.ReadFull(file, header)
+	header = header[:read]
+	contentType := scanContentType(header)
+	if contentType == "" {
+		h.logger.WithField("path", path).Warnln("Scan is not a supported image")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// sideways photos are turned upright, which needs the whole image
+	rawWidth := r.URL.Query().Get("w")
+	if rawWidth != "" || !browserImageTypes[contentType] || recipe.ImageOrientation(header) != 1 {
+		stat, err := file.Stat()
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		key := fmt.Sprintf("scan:%s:%d:%d", path, stat.ModTime().UnixNano(), stat.Size())
+		h.thumbnail(w, rawWidth, key, func() ([]byte, error) {
+			return ioutil.ReadAll(file)
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
 	io.Copy(w, file)
 }
 
+// thumbnail writes the upright image resized to the requested width, or at its own size if there is no width
+func (h *Handler) thumbnail(w http.ResponseWriter, rawWidth, key string, source func() ([]byte, error)) {
+	width := 0
+	if rawWidth != "" {
+		var err error
+		width, err = thumbnailWidth(rawWidth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	data, err := h.thumbnails.get(key, width, source)
+	if err != nil {
+		h.logger.WithError(err).WithField("key", key).Errorln("Failed to create thumbnail")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	// the key changes with the image, but the URL does not
+	w.Header().Set("Cache-Control", "max-age=3600")
+	w.Write(data)
+}
+
 func (h *Handler) pathToURL(filePath, pattern string) (string, error) {
 	path, err := filepath.Rel(h.recipePath, filePath)
 	if err != nil {
@@ -468,6 +832,8 @@ func (h *Handler) pathToURL(filePath, pattern string) (string, error) {
 	return urlPath, nil
 }
 
+// urlToPath converts a relative URL below pattern to a path in the recipes folder
+// it is empty when the URL is not in the recipes folder, such as one with ".."
 func (h *Handler) urlToPath(url, pattern string) string {
 	path := filepath.Join(
 		h.recipePath,
@@ -483,6 +849,16 @@ func (h *Handler) urlToPath(url, pattern string) string {
 		return ""
 	}
 
+	rel, err := filepath.Rel(h.recipePath, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		log.WithFields(log.Fields{
+			"url":     url,
+			"pattern": pattern,
+			"path":    path,
+		}).Warnln("Refusing path outside of the recipes folder")
+		return ""
+	}
+
 	return path
 }
 
@@ -492,8 +868,14 @@ func (h *Handler) recipeToTemplateRecipe(rec *recipe.Recipe) *TemplateRecipe {
 		ID:         rec.Title,
 		URL:        "/recipe/" + url.PathEscape(rec.Title),
 		StockImage: stockImagePatten + url.PathEscape(rec.Title+".jpg"),
+		EditURL:    editRecipeURL(rec.Title),
+		ScansURL:   recipeScansURL(rec.Title),
+		PrintURL:   recipePrintURL(rec.Title),
+		PDFURL:     recipePDFURL(rec.Title),
 	}
 
+	tmplRecipe.StockImageSrcset = thumbnailSrcset(tmplRecipe.StockImage)
+
 	docxURL, err := h.pathToURL(rec.DocxPath, docxPattern)
 	if err != nil {
 		log.WithError(err).WithField("docxPath", rec.DocxPath).Warnln(
@@ -503,6 +885,22 @@ func (h *Handler) recipeToTemplateRecipe(rec *recipe.Recipe) *TemplateRecipe {
 		tmplRecipe.DocxURL = docxURL
 	}
 
+	for _, attachment := range rec.Attachments {
+		urlPath, err := h.pathToURL(attachment.Path, attachmentPattern)
+		if err != nil {
+			continue
+		}
+
+		tmplRecipe.Attachments = append(
+			tmplRecipe.Attachments,
+			&TemplateAttachment{
+				Name:  filepath.Base(attachment.Path),
+				URL:   urlPath,
+				Pages: attachment.Pages,
+			},
+		)
+	}
+
 	for _, imagePath := range rec.ScanPaths {
 		urlPath, err := h.pathToURL(imagePath, imagePattern)
 		if err != nil {
@@ -511,7 +909,11 @@ func (h *Handler) recipeToTemplateRecipe(rec *recipe.Recipe) *TemplateRecipe {
 
 		tmplRecipe.Images = append(
 			tmplRecipe.Images,
-			urlPath,
+			&TemplateImage{
+				URL:       urlPath,
+				Thumbnail: thumbnailURL(urlPath, thumbnailWidths[1]),
+				Srcset:    thumbnailSrcset(urlPath),
+			},
 		)
 	}
 
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestURLToPath(t *testing.T) {
+	handler := &Handler{recipePath: filepath.Join(string(filepath.Separator), "recipes")}
+	tests := map[string]string{
+		"/attachments/Baking/card.pdf":       filepath.Join(handler.recipePath, "Baking", "card.pdf"),
+		"/attachments/Baking/../card.pdf":    filepath.Join(handler.recipePath, "card.pdf"),
+		"/attachments/../etc/passwd":         "",
+		"/attachments/Baking/../../card.pdf": "",
+		"/attachments/..":                    "",
+	}
+
+	for url, expected := range tests {
+		if path := handler.urlToPath(url, attachmentPattern); path != expected {
+			t.Errorf("urlToPath(\"%s\") != \"%s\": \"%s\"", url, expected, path)
+		}
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-attachments")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	recipePath := filepath.Join(dir, "recipes")
+	files := map[string]string{
+		filepath.Join(recipePath, "Toast", "card.pdf"): "%PDF-1.4\n<< /Type /Page >>\n",
+		filepath.Join(recipePath, "Toast", "fake.pdf"): "<html></html>",
+		filepath.Join(dir, "secret.pdf"):               "%PDF-1.4 secret",
+	}
+
+	for path, data := range files {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(path, []byte(data), 0644)
+		}
+
+		if err != nil {
+			t.Fatal("Failed to write", path, err)
+		}
+	}
+
+	handler := &Handler{recipePath: recipePath}
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{"/attachments/Toast/card.pdf", http.StatusOK},
+		{"/attachments/Toast/fake.pdf", http.StatusNotFound},
+		{"/attachments/Toast/missing.pdf", http.StatusNotFound},
+		{"/attachments/../secret.pdf", http.StatusNotFound},
+		{"/attachments/Toast/..%2F..%2Fsecret.pdf", http.StatusNotFound},
+		{"/attachments/Toast", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.URL.Path = strings.Replace(test.url, "%2F", "/", -1)
+		recorder := httptest.NewRecorder()
+		handler.Attachments(recorder, request)
+		if recorder.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.url, test.status, recorder.Code)
+			continue
+		}
+
+		if test.status != http.StatusOK {
+			continue
+		}
+
+		if contentType := recorder.Header().Get("Content-Type"); contentType != "application/pdf" {
+			t.Errorf("%s: expected a PDF, got \"%s\"", test.url, contentType)
+		}
+
+		if !strings.HasPrefix(recorder.Body.String(), "%PDF-") {
+			t.Errorf("%s: unexpected body \"%s\"", test.url, recorder.Body.String())
+		}
+	}
+}
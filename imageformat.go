@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+
+	// decoders for the scan formats, image.Decode finds them by their magic bytes
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// scanExtensions are the file extensions scans of each content type are saved with
+var scanExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/tiff": ".tiff",
+}
+
+// browserImageTypes are the content types served as they are, anything else is converted to a JPEG
+var browserImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// scanContentType sniffs the content type of a scan from its first bytes
+// it is empty when the data is not a supported image
+func scanContentType(header []byte) string {
+	// http.DetectContentType does not know TIFF
+	if bytes.HasPrefix(header, []byte("II*\x00")) || bytes.HasPrefix(header, []byte("MM\x00*")) {
+		return "image/tiff"
+	}
+
+	contentType := http.DetectContentType(header)
+	if _, exists := scanExtensions[contentType]; !exists {
+		return ""
+	}
+
+	return contentType
+}
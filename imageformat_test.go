@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+// encodeTestImages encodes a 40x20 image in every format that can be written
+func encodeTestImages(t *testing.T) map[string][]byte {
+	img := image.NewGray(image.Rect(0, 0, 40, 20))
+	encoders := map[string]func(buf *bytes.Buffer) error{
+		"image/jpeg": func(buf *bytes.Buffer) error { return jpeg.Encode(buf, img, nil) },
+		"image/png":  func(buf *bytes.Buffer) error { return png.Encode(buf, img) },
+		"image/gif":  func(buf *bytes.Buffer) error { return gif.Encode(buf, img, nil) },
+		"image/tiff": func(buf *bytes.Buffer) error { return tiff.Encode(buf, img, nil) },
+	}
+
+	images := make(map[string][]byte)
+	for contentType, encode := range encoders {
+		buf := new(bytes.Buffer)
+		err := encode(buf)
+		if err != nil {
+			t.Fatal("Failed to encode", contentType, err)
+		}
+
+		images[contentType] = buf.Bytes()
+	}
+
+	return images
+}
+
+func TestScanContentType(t *testing.T) {
+	for contentType, data := range encodeTestImages(t) {
+		if found := scanContentType(data); found != contentType {
+			t.Errorf("scanContentType != \"%s\": \"%s\"", contentType, found)
+		}
+	}
+
+	webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8 ")
+	if found := scanContentType(webp); found != "image/webp" {
+		t.Errorf("scanContentType != \"image/webp\": \"%s\"", found)
+	}
+
+	for _, data := range []string{"", "%PDF-1.4", "<html></html>"} {
+		if found := scanContentType([]byte(data)); found != "" {
+			t.Errorf("Expected no content type for \"%s\", got \"%s\"", data, found)
+		}
+	}
+}
+
+func TestImagesFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-images")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	handler := newTestHandler(t)
+	handler.recipePath = dir
+	handler.thumbnails = &thumbnailCache{}
+
+	images := encodeTestImages(t)
+	tests := []struct {
+		name        string
+		data        []byte
+		status      int
+		contentType string
+	}{
+		{"card.jpg", images["image/jpeg"], http.StatusOK, "image/jpeg"},
+		// the extension does not matter, only what the file is
+		{"card.jpeg", images["image/png"], http.StatusOK, "image/png"},
+		{"card.png", images["image/png"], http.StatusOK, "image/png"},
+		{"card.gif", images["image/gif"], http.StatusOK, "image/gif"},
+		{"card.tif", images["image/tiff"], http.StatusOK, "image/jpeg"},
+		{"card.webp", []byte("not an image"), http.StatusNotFound, ""},
+		{"card.pdf", []byte("%PDF-1.4"), http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		err = ioutil.WriteFile(filepath.Join(dir, test.name), test.data, 0644)
+		if err != nil {
+			t.Fatal("Failed to write", test.name, err)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.Images(recorder, httptest.NewRequest(http.MethodGet, imagePattern+test.name, nil))
+		if recorder.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, recorder.Code)
+			continue
+		}
+
+		if test.status != http.StatusOK {
+			continue
+		}
+
+		if contentType := recorder.Header().Get("Content-Type"); contentType != test.contentType {
+			t.Errorf("%s: expected Content-Type \"%s\", got \"%s\"", test.name, test.contentType, contentType)
+		}
+
+		config, _, err := image.DecodeConfig(recorder.Body)
+		if err != nil {
+			t.Errorf("%s: served image does not decode: %s", test.name, err)
+		} else if config.Width != 40 || config.Height != 20 {
+			t.Errorf("%s: expected a 40x20 image, got %dx%d", test.name, config.Width, config.Height)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+const (
+	importRecipePattern = "/recipes/import"
+
+	// importMaxPageSize is the largest web page accepted for importing
+	importMaxPageSize = 10 << 20
+)
+
+// importJPEG converts the image of an imported recipe to the JPEG stock images are kept as
+func importJPEG(data []byte) ([]byte, error) {
+	contentType := scanContentType(data)
+	if contentType == "image/jpeg" {
+		return data, nil
+	}
+
+	if contentType == "" {
+		return nil, &RecipeEditError{http.StatusUnsupportedMediaType, "The image is not a JPEG, PNG, GIF, WebP or TIFF image"}
+	}
+
+	return resizeToJPEG(data, 0)
+}
+
+// importImage finds the first image of a saved web page that is on disk, either where the page
+// links to or, for images on other sites, in the folder the browser saved the page's files in
+// images are never downloaded, nil is returned when none of them were saved
+func importImage(pagePath string, imageURLs []string) []byte {
+	dir := filepath.Dir(pagePath)
+	filesDir := strings.TrimSuffix(pagePath, filepath.Ext(pagePath)) + "_files"
+	for _, imageURL := range imageURLs {
+		parsed, err := url.Parse(imageURL)
+		if err != nil {
+			continue
+		}
+
+		candidates := []string{}
+		switch parsed.Scheme {
+		case "":
+			candidates = append(candidates, filepath.Join(dir, filepath.FromSlash(parsed.Path)))
+		case "file":
+			candidates = append(candidates, filepath.FromSlash(parsed.Path))
+		default:
+			name := path.Base(parsed.Path)
+			candidates = append(candidates, filepath.Join(filesDir, name), filepath.Join(dir, name))
+		}
+
+		for _, candidate := range candidates {
+			data, err := ioutil.ReadFile(candidate)
+			if err == nil && scanContentType(data) != "" {
+				return data
+			}
+		}
+	}
+
+	return nil
+}
+
+// importRecipe creates a recipe in category from the schema.org data of a web page
+// image is used as the stock image when it is set, otherwise the page's own image is looked
+// for next to pagePath, which is empty for uploaded pages
+func (h *Handler) importRecipe(page io.Reader, pagePath, category string, image []byte) (*recipe.Recipe, error) {
+	rec, imageURLs, err := recipe.ParseHTML(page)
+	if err != nil {
+		return nil, &RecipeEditError{http.StatusBadRequest, "Unable to import the page: " + err.Error()}
+	}
+
+	if len(image) == 0 && pagePath != "" {
+		image = importImage(pagePath, imageURLs)
+	}
+
+	if len(image) > 0 {
+		image, err = importJPEG(image)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rec.Category = category
+
+	return h.addRecipe(rec, image)
+}
+
+// importRecipeFiles imports the saved web pages at pagePaths into category, for the command line
+// every page is tried, the number that could not be imported is returned
+func (h *Handler) importRecipeFiles(pagePaths []string, category string) (failed int) {
+	for _, pagePath := range pagePaths {
+		file, err := os.Open(pagePath)
+		if err != nil {
+			h.logger.WithError(err).WithField("page", pagePath).Errorln("Failed to open page")
+			failed++
+			continue
+		}
+
+		created, err := h.importRecipe(file, pagePath, category, nil)
+		file.Close()
+		if err != nil {
+			h.logger.WithError(err).WithField("page", pagePath).Errorln("Failed to import page")
+			failed++
+			continue
+		}
+
+		h.logger.WithFields(log.Fields{
+			"page":        pagePath,
+			"recipeTitle": created.Title,
+		}).Infoln("Imported")
+	}
+
+	return
+}
+
+// ImportRecipe handles the form for importing a recipe from a saved web page
+func (h *Handler) ImportRecipe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	tmplData := &TemplateData{
+		PageTitle: "Recipe Card - Import recipe",
+		Import: &TemplateImport{
+			Categories: h.recipeCategories(),
+		},
+	}
+
+	if r.Method == http.MethodPost {
+		created, err := h.importRecipeFromForm(w, r)
+		if err == nil {
+			http.Redirect(w, r, recipePattern+url.PathEscape(created.Title), http.StatusSeeOther)
+			return
+		}
+
+		tmplData.Import.Category = r.FormValue("category")
+		if editErr, ok := err.(*RecipeEditError); ok {
+			tmplData.Import.Error = editErr.Reason
+			w.WriteHeader(editErr.Status)
+		} else {
+			h.logger.WithError(err).Errorln("Failed to import recipe")
+			tmplData.Import.Error = "Unable to import the recipe right now: " + err.Error()
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+
+	h.templates.ExecuteTemplate(w, "import", tmplData)
+}
+
+// importRecipeFromForm creates a recipe from the page and optional image of the posted import form
+func (h *Handler) importRecipeFromForm(w http.ResponseWriter, r *http.Request) (*recipe.Recipe, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, importMaxPageSize+newRecipeMaxImageSize+1<<20)
+	err := r.ParseMultipartForm(1 << 20)
+	if err != nil && err != http.ErrNotMultipart {
+		return nil, &RecipeEditError{http.StatusRequestEntityTooLarge, "The form is too large, the page and image must each be smaller than 10 MB"}
+	}
+
+	page, pageHeader, err := r.FormFile("page")
+	if err != nil {
+		return nil, &RecipeEditError{http.StatusBadRequest, "Choose a saved web page to import"}
+	}
+
+	defer page.Close()
+
+	if pageHeader.Size > importMaxPageSize {
+		return nil, &RecipeEditError{http.StatusRequestEntityTooLarge, "The page must be smaller than 10 MB"}
+	}
+
+	var image []byte
+	file, header, err := r.FormFile("image")
+	if err != nil && err != http.ErrMissingFile {
+		return nil, &RecipeEditError{http.StatusBadRequest, "Unable to read the image"}
+	}
+
+	if err == nil {
+		defer file.Close()
+
+		if header.Size > newRecipeMaxImageSize {
+			return nil, &RecipeEditError{http.StatusRequestEntityTooLarge, "The image must be smaller than 10 MB"}
+		}
+
+		image, err = ioutil.ReadAll(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return h.importRecipe(page, "", r.FormValue("category"), image)
+}
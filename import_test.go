@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const importPage = `<!doctype html>
+<html><head><script type="application/ld+json">
+{"@context": "https://schema.org", "@type": "Recipe", "name": "Lemon Bars",
+	"author": {"@type": "Person", "name": "Ada"},
+	"image": ["https://example.com/photos/lemon-bars.png"],
+	"recipeYield": "16 bars",
+	"recipeIngredient": ["1 cup butter", "4 lemons"],
+	"recipeInstructions": "Bake the crust.\nPour on the filling and bake for 25 minutes."}
+</script></head><body></body></html>`
+
+func TestImportRecipeFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-import")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	handler := newTestHandler(t)
+	handler.recipePath = filepath.Join(dir, "recipes")
+
+	// browsers save the images of a page in a folder next to it
+	buf := new(bytes.Buffer)
+	err = png.Encode(buf, image.NewGray(image.Rect(0, 0, 40, 20)))
+	if err != nil {
+		t.Fatal("Failed to encode PNG", err)
+	}
+
+	pagePath := filepath.Join(dir, "Lemon Bars.html")
+	files := map[string][]byte{
+		pagePath: []byte(importPage),
+		filepath.Join(dir, "Lemon Bars_files", "lemon-bars.png"): buf.Bytes(),
+		filepath.Join(dir, "article.html"):                       []byte("<p>No recipe here</p>"),
+	}
+
+	for path, data := range files {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = ioutil.WriteFile(path, data, 0644)
+		}
+
+		if err != nil {
+			t.Fatal("Failed to write", path, err)
+		}
+	}
+
+	failed := handler.importRecipeFiles([]string{pagePath, filepath.Join(dir, "article.html"), filepath.Join(dir, "missing.html")}, "Desserts")
+	if failed != 2 {
+		t.Errorf("Expected 2 pages to fail, got %d", failed)
+	}
+
+	rec, exists := handler.getRecipe("Lemon Bars")
+	if !exists {
+		t.Fatal("Expected Lemon Bars to be imported")
+	}
+
+	if rec.Category != "Desserts" || rec.Author != "Ada" {
+		t.Errorf("Expected Lemon Bars by Ada in Desserts, got %s by %s", rec.Category, rec.Author)
+	}
+
+	if strings.Join(rec.Info["preparation"], "|") != "Bake the crust.|Pour on the filling and bake for 25 minutes." {
+		t.Errorf("Expected the preparation lines, got %v", rec.Info["preparation"])
+	}
+
+	if strings.Join(rec.Info["serves"], "|") != "16 bars" {
+		t.Errorf("Expected to serve 16 bars, got %v", rec.Info["serves"])
+	}
+
+	// the PNG is stored as the JPEG stock image
+	config, format, err := image.DecodeConfig(bytes.NewReader(rec.Image))
+	if err != nil || format != "jpeg" || config.Width != 40 {
+		t.Errorf("Expected the saved image as a 40 pixel wide JPEG, got %s %d %v", format, config.Width, err)
+	}
+
+	// importing again would take the title twice
+	if failed := handler.importRecipeFiles([]string{pagePath}, ""); failed != 1 {
+		t.Errorf("Expected importing Lemon Bars again to fail, got %d failures", failed)
+	}
+}
+
+func TestImportRecipe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-import")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	handler := newTestHandler(t)
+	handler.recipePath = dir
+	handler.templates, err = NewTemplate(nil)
+	if err != nil {
+		t.Fatal("Failed to parse templates", err)
+	}
+
+	post := func(page string) *httptest.ResponseRecorder {
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		writer.WriteField("category", "Desserts")
+		part, err := writer.CreateFormFile("page", "page.html")
+		if err != nil {
+			t.Fatal("Failed to create form file", err)
+		}
+
+		part.Write([]byte(page))
+		writer.Close()
+
+		request := httptest.NewRequest(http.MethodPost, importRecipePattern, body)
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		recorder := httptest.NewRecorder()
+		handler.ImportRecipe(recorder, request)
+		return recorder
+	}
+
+	recorder := post(importPage)
+	if recorder.Code != http.StatusSeeOther || recorder.Header().Get("Location") != "/recipe/Lemon%20Bars" {
+		t.Fatalf("Expected a redirect to Lemon Bars, got %d %s", recorder.Code, recorder.Header().Get("Location"))
+	}
+
+	// uploaded pages have no saved images, so the placeholder is used
+	if rec, exists := handler.getRecipe("Lemon Bars"); !exists || len(rec.Image) == 0 {
+		t.Error("Expected Lemon Bars with a stock image")
+	}
+
+	recorder = post("<p>No recipe here</p>")
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "no schema.org Recipe") {
+		t.Errorf("Expected status 400 for a page without a recipe, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ImportRecipe(recorder, httptest.NewRequest(http.MethodPost, importRecipePattern, nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a page, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ImportRecipe(recorder, httptest.NewRequest(http.MethodGet, importRecipePattern, nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `<option value="Baking">`) {
+		t.Errorf("Expected the import form with the categories, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/analysis/lang/de"
+	"github.com/blevesearch/bleve/analysis/lang/en"
+	"github.com/blevesearch/bleve/analysis/lang/es"
+	"github.com/blevesearch/bleve/analysis/lang/it"
+	"github.com/blevesearch/bleve/analysis/token/edgengram"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/token/porter"
+	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
+	blevemapping "github.com/blevesearch/bleve/mapping"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+const (
+	// indexVersion must be bumped whenever the index mapping or the
+	// indexed document changes so old indexes get rebuilt
+	indexVersion = "8"
+
+	// indexVersionKey is the internal bleve key that stores indexVersion
+	indexVersionKey = "recipe-card-index-version"
+
+	// suggestAnalyzer indexes every prefix of every word for suggestions
+	suggestAnalyzer = "suggest"
+
+	// suggestQueryAnalyzer analyzes typed text to match suggestAnalyzer prefixes
+	suggestQueryAnalyzer = "suggest_query"
+)
+
+// languageAnalyzers are the analyzers of the languages recipes are indexed in
+var languageAnalyzers = map[string]string{
+	"en": cookingAnalyzer,
+	"de": de.AnalyzerName,
+	"it": it.AnalyzerName,
+	"es": es.AnalyzerName,
+}
+
+// analyzedFields are the fields analyzed in the language of the recipe
+var analyzedFields = map[string]bool{
+	"":                 true,
+	"_all":             true,
+	"title":            true,
+	"serves":           true,
+	"oven_temperature": true,
+	"ingredients":      true,
+	"ingredient_names": true,
+	"preparation":      true,
+	"tips":             true,
+}
+
+// fieldBoosts defines how much each indexed field matters for search relevance
+var fieldBoosts = map[string]float64{
+	"title":            4,
+	"tags":             3,
+	"ingredients":      2,
+	"preparation":      1,
+	"tips":             0.5,
+	"serves":           0.5,
+	"oven_temperature": 0.5,
+}
+
+// recipeDocument is what actually gets indexed for a recipe.Recipe
+// this leaves out the paths and image data that are useless for searching
+type recipeDocument struct {
+	Title           string    `json:"title"`
+	Serves          string    `json:"serves"`
+	OvenTemperature string    `json:"oven_temperature"`
+	Ingredients     string    `json:"ingredients"`
+	IngredientNames []string  `json:"ingredient_names"`
+	Preparation     string    `json:"preparation"`
+	Tips            string    `json:"tips"`
+	Tags            []string  `json:"tags"`
+	Servings        float64   `json:"servings"`
+	Temperature     float64   `json:"temperature"`
+	TotalMinutes    float64   `json:"total_minutes"`
+	SortTitle       string    `json:"sort_title"`
+	Category        string    `json:"category"`
+	Author          string    `json:"author"`
+	Created         time.Time `json:"created"`
+	Modified        time.Time `json:"modified"`
+	Language        string    `json:"language"`
+}
+
+// Type is the bleve document type, which is the language of the recipe
+func (d *recipeDocument) Type() string {
+	return d.Language
+}
+
+// searchableField is a query that can be limited to a field and boosted
+type searchableField interface {
+	query.Query
+	SetField(string)
+	SetBoost(float64)
+}
+
+// newRecipeDocument converts a recipe.Recipe to a recipeDocument
+func newRecipeDocument(rec *recipe.Recipe) *recipeDocument {
+	tags := make([]string, 0, len(rec.Tags))
+	for _, tag := range rec.Tags {
+		tags = append(tags, strings.ToLower(tag))
+	}
+
+	ingredientNames := []string{}
+	for _, ingredient := range rec.Ingredients() {
+		ingredientNames = append(ingredientNames, ingredient.Name)
+	}
+
+	return &recipeDocument{
+		Title:           rec.Title,
+		Serves:          strings.Join(rec.Info["serves"], "\n"),
+		OvenTemperature: strings.Join(rec.Info["oven temperature"], "\n"),
+		Ingredients:     strings.Join(rec.Info["ingredients"], "\n"),
+		IngredientNames: ingredientNames,
+		Preparation:     strings.Join(rec.Info["preparation"], "\n"),
+		Tips:            strings.Join(rec.Info["tips"], "\n"),
+		Tags:            tags,
+		Servings:        rec.Servings(),
+		Temperature:     rec.OvenTemperature(),
+		TotalMinutes:    rec.TotalMinutes(),
+		SortTitle:       strings.ToLower(rec.Title),
+		Category:        rec.Category,
+		Author:          rec.Author,
+		Created:         rec.Created,
+		Modified:        rec.Modified,
+		Language:        rec.Language,
+	}
+}
+
+// indexVersionFor is the index version for the synonyms
+// changing the synonyms needs the index to be rebuilt too
+func indexVersionFor(synonyms []string) string {
+	sha256sum := sha256.Sum256([]byte(strings.Join(synonyms, "\n")))
+	return indexVersion + "-" + hex.EncodeToString(sha256sum[:8])
+}
+
+// newDocumentMapping creates the mapping of a recipeDocument with text in
+// the language of textAnalyzer
+func newDocumentMapping(textAnalyzer string) *blevemapping.DocumentMapping {
+	// text is stored with term vectors so matches can be highlighted
+	textField := func() *blevemapping.FieldMapping {
+		field := bleve.NewTextFieldMapping()
+		field.Analyzer = textAnalyzer
+		field.Store = true
+		field.IncludeTermVectors = true
+		return field
+	}
+
+	numericField := func() *blevemapping.FieldMapping {
+		field := bleve.NewNumericFieldMapping()
+		field.Store = false
+		return field
+	}
+
+	// ingredient names duplicate ingredients, keep them out of _all
+	ingredientNameField := textField()
+	ingredientNameField.IncludeInAll = false
+	ingredientNameField.Store = false
+
+	keywordField := func() *blevemapping.FieldMapping {
+		field := bleve.NewTextFieldMapping()
+		field.Analyzer = keyword.Name
+		field.Store = false
+		return field
+	}
+
+	// these are only for sorting and faceting
+	internalField := func(field *blevemapping.FieldMapping) *blevemapping.FieldMapping {
+		field.IncludeInAll = false
+		return field
+	}
+
+	dateField := func() *blevemapping.FieldMapping {
+		field := bleve.NewDateTimeFieldMapping()
+		field.Store = false
+		return field
+	}
+
+	// prefixes of titles and ingredients for suggestions while typing
+	suggestField := func(name string) *blevemapping.FieldMapping {
+		field := bleve.NewTextFieldMapping()
+		field.Name = name
+		field.Analyzer = suggestAnalyzer
+		field.Store = false
+		field.IncludeInAll = false
+		field.IncludeTermVectors = false
+		return field
+	}
+
+	// whole ingredient names for suggestions
+	ingredientKeywordField := internalField(keywordField())
+	ingredientKeywordField.Name = "ingredient_keywords"
+
+	// only explicitly mapped fields get indexed
+	docMapping := bleve.NewDocumentStaticMapping()
+	docMapping.AddFieldMappingsAt("title", textField(), suggestField("suggest_title"))
+	docMapping.AddFieldMappingsAt("serves", textField())
+	docMapping.AddFieldMappingsAt("oven_temperature", textField())
+	docMapping.AddFieldMappingsAt("ingredients", textField())
+	docMapping.AddFieldMappingsAt(
+		"ingredient_names",
+		ingredientNameField,
+		suggestField("suggest_ingredients"),
+		ingredientKeywordField,
+	)
+	docMapping.AddFieldMappingsAt("preparation", textField())
+	docMapping.AddFieldMappingsAt("tips", textField())
+	docMapping.AddFieldMappingsAt("tags", keywordField())
+	docMapping.AddFieldMappingsAt("servings", numericField())
+	docMapping.AddFieldMappingsAt("temperature", numericField())
+	docMapping.AddFieldMappingsAt("total_minutes", internalField(numericField()))
+	docMapping.AddFieldMappingsAt("sort_title", internalField(keywordField()))
+	docMapping.AddFieldMappingsAt("category", internalField(keywordField()))
+	docMapping.AddFieldMappingsAt("author", keywordField())
+	docMapping.AddFieldMappingsAt("created", internalField(dateField()))
+	docMapping.AddFieldMappingsAt("modified", internalField(dateField()))
+	docMapping.AddFieldMappingsAt("language", internalField(keywordField()))
+	docMapping.DefaultAnalyzer = textAnalyzer
+
+	return docMapping
+}
+
+// NewIndexMapping creates the bleve index mapping for recipe documents
+// english text is analyzed with the given cooking synonym groups
+func NewIndexMapping(synonyms []string) (*blevemapping.IndexMappingImpl, error) {
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultAnalyzer = cookingAnalyzer
+	indexMapping.DefaultMapping = newDocumentMapping(cookingAnalyzer)
+	indexMapping.StoreDynamic = false
+
+	// recipeDocument.Type picks the mapping for its language
+	for language, analyzer := range languageAnalyzers {
+		if language != recipe.DefaultLanguage {
+			indexMapping.AddDocumentMapping(language, newDocumentMapping(analyzer))
+		}
+	}
+
+	// the config must be plain JSON types since it is saved with the index
+	synonymConfig := make([]interface{}, 0, len(synonyms))
+	for _, group := range synonyms {
+		synonymConfig = append(synonymConfig, group)
+	}
+
+	err := indexMapping.AddCustomTokenFilter(synonymFilterName, map[string]interface{}{
+		"type":     synonymFilterType,
+		"synonyms": synonymConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = indexMapping.AddCustomAnalyzer(cookingAnalyzer, map[string]interface{}{
+		"type":      custom.Name,
+		"tokenizer": unicode.Name,
+		"token_filters": []string{
+			en.PossessiveName,
+			lowercase.Name,
+			en.StopName,
+			porter.Name,
+			synonymFilterName,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = indexMapping.AddCustomTokenFilter("suggest_edge_ngram", map[string]interface{}{
+		"type": edgengram.Name,
+		"min":  1.0,
+		"max":  25.0,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = indexMapping.AddCustomAnalyzer(suggestAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name, "suggest_edge_ngram"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = indexMapping.AddCustomAnalyzer(suggestQueryAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return indexMapping, nil
+}
+
+// newSearchQuery creates a query across every indexed field with its boost
+func newSearchQuery(search string, fuzzy bool) query.Query {
+	queries := make([]query.Query, 0, len(fieldBoosts))
+	for field, boost := range fieldBoosts {
+		var fieldQuery searchableField
+		if field == "tags" {
+			// tags are lowercased keywords, so the whole search must match
+			fieldQuery = bleve.NewTermQuery(strings.ToLower(search))
+		} else {
+			matchQuery := bleve.NewMatchQuery(search)
+			if fuzzy {
+				matchQuery.SetFuzziness(1)
+			}
+
+			fieldQuery = matchQuery
+		}
+
+		fieldQuery.SetField(field)
+		fieldQuery.SetBoost(boost)
+		queries = append(queries, fieldQuery)
+	}
+
+	return localizeQuery(bleve.NewDisjunctionQuery(queries...))
+}
+
+// localizeQuery makes match queries use the analyzer of every language
+// bleve would otherwise pick the analyzer of any one document mapping
+// since the fields of every language have the same name
+func localizeQuery(q query.Query) query.Query {
+	switch q := q.(type) {
+	case *query.BooleanQuery:
+		if q.Must != nil {
+			q.Must = localizeQuery(q.Must)
+		}
+
+		if q.Should != nil {
+			q.Should = localizeQuery(q.Should)
+		}
+
+		if q.MustNot != nil {
+			q.MustNot = localizeQuery(q.MustNot)
+		}
+
+	case *query.ConjunctionQuery:
+		for i, conjunct := range q.Conjuncts {
+			q.Conjuncts[i] = localizeQuery(conjunct)
+		}
+
+	case *query.DisjunctionQuery:
+		for i, disjunct := range q.Disjuncts {
+			q.Disjuncts[i] = localizeQuery(disjunct)
+		}
+
+	case *query.MatchQuery:
+		return localizedQuery(q, q.Field(), q.Analyzer, func(analyzer string) query.Query {
+			languageQuery := *q
+			languageQuery.Analyzer = analyzer
+			return &languageQuery
+		})
+
+	case *query.MatchPhraseQuery:
+		return localizedQuery(q, q.Field(), q.Analyzer, func(analyzer string) query.Query {
+			languageQuery := *q
+			languageQuery.Analyzer = analyzer
+			return &languageQuery
+		})
+	}
+
+	return q
+}
+
+// localizedQuery ors a copy of an analyzed query for the analyzer of every language
+// withAnalyzer copies the query with another analyzer
+func localizedQuery(q query.Query, field string, analyzer string, withAnalyzer func(string) query.Query) query.Query {
+	if analyzer != "" || !analyzedFields[field] {
+		return q
+	}
+
+	localized := make([]query.Query, 0, len(languageAnalyzers))
+	for _, languageAnalyzer := range languageAnalyzers {
+		localized = append(localized, withAnalyzer(languageAnalyzer))
+	}
+
+	return bleve.NewDisjunctionQuery(localized...)
+}
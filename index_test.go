@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+func TestLocalizeQuery(t *testing.T) {
+	handler := newTestHandler(t)
+
+	// the german analyzer makes äpfel apfel and tomaten tomat, the english one leaves them be
+	addTestRecipe(t, handler, &recipe.Recipe{Title: "Äpfel und Tomaten mit Quark", Language: "de"})
+	addTestRecipe(t, handler, &recipe.Recipe{Title: "Äpfel and Tomaten", Language: "en"})
+
+	tests := map[string][]string{
+		"apfel":       {"Äpfel und Tomaten mit Quark"},
+		"tomate":      {"Äpfel und Tomaten mit Quark"},
+		"äpfel":       {"Äpfel and Tomaten", "Äpfel und Tomaten mit Quark"},
+		`"mit quark"`: {"Äpfel und Tomaten mit Quark"},
+	}
+
+	for search, expected := range tests {
+		q, err := parseSearch(search, false)
+		if err != nil {
+			t.Fatal("Failed to parse", search, err)
+		}
+
+		results, err := handler.idx.Search(bleve.NewSearchRequest(q))
+		if err != nil {
+			t.Fatal("Failed to search", search, err)
+		}
+
+		found := map[string]bool{}
+		for _, hit := range results.Hits {
+			found[hit.ID] = true
+		}
+
+		if len(found) != len(expected) {
+			t.Errorf("Expected %v for %s, got %d results", expected, search, len(found))
+		}
+
+		for _, title := range expected {
+			if !found[title] {
+				t.Errorf("Expected %s to find %s", search, title)
+			}
+		}
+	}
+
+	// queries with their own analyzer or unanalyzed fields are left alone
+	matchQuery := bleve.NewMatchQuery("apfel")
+	matchQuery.Analyzer = "standard"
+	if localizeQuery(matchQuery) != matchQuery {
+		t.Error("Expected a match query with an analyzer to be left alone")
+	}
+
+	phraseQuery := bleve.NewMatchPhraseQuery("mit quark")
+	phraseQuery.SetField("tags")
+	if localizeQuery(phraseQuery) != phraseQuery {
+		t.Error("Expected a phrase query on tags to be left alone")
+	}
+
+	disjunction, ok := localizeQuery(bleve.NewMatchPhraseQuery("mit quark")).(*query.DisjunctionQuery)
+	if !ok || len(disjunction.Disjuncts) != len(languageAnalyzers) {
+		t.Error("Expected a phrase query for every language")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+// RecipeJSONLD is a schema.org Recipe, embedded in recipe pages for other programs to read
+type RecipeJSONLD struct {
+	Context string        `json:"@context"`
+	Type    string        `json:"@type"`
+	Name    string        `json:"name"`
+	URL     string        `json:"url,omitempty"`
+	Author  *PersonJSONLD `json:"author,omitempty"`
+	Image   []string      `json:"image,omitempty"`
+	// how many the recipe serves, as written
+	RecipeYield        []string           `json:"recipeYield,omitempty"`
+	RecipeIngredient   []string           `json:"recipeIngredient"`
+	RecipeInstructions []*HowToStepJSONLD `json:"recipeInstructions"`
+	// ISO 8601 duration, such as PT1H30M
+	TotalTime      string `json:"totalTime,omitempty"`
+	RecipeCategory string `json:"recipeCategory,omitempty"`
+	Keywords       string `json:"keywords,omitempty"`
+	InLanguage     string `json:"inLanguage,omitempty"`
+	DateCreated    string `json:"dateCreated,omitempty"`
+	DateModified   string `json:"dateModified,omitempty"`
+}
+
+// PersonJSONLD is a schema.org Person
+type PersonJSONLD struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// HowToStepJSONLD is a schema.org HowToStep, a line of the preparation
+type HowToStepJSONLD struct {
+	Type string `json:"@type"`
+	Text string `json:"text"`
+}
+
+// newRecipeJSONLD describes the recipe, using the URLs of the recipe's template
+func newRecipeJSONLD(rec *recipe.Recipe, tmplRecipe *TemplateRecipe) *RecipeJSONLD {
+	jsonLD := &RecipeJSONLD{
+		Context:            "https://schema.org",
+		Type:               "Recipe",
+		Name:               rec.Title,
+		URL:                tmplRecipe.URL,
+		RecipeYield:        rec.Info["serves"],
+		RecipeIngredient:   rec.Info["ingredients"],
+		RecipeInstructions: []*HowToStepJSONLD{},
+		TotalTime:          isoDuration(rec.TotalMinutes()),
+		RecipeCategory:     rec.Category,
+		Keywords:           strings.Join(rec.Tags, ", "),
+		InLanguage:         rec.Language,
+	}
+
+	if jsonLD.RecipeIngredient == nil {
+		jsonLD.RecipeIngredient = []string{}
+	}
+
+	if rec.Author != "" {
+		jsonLD.Author = &PersonJSONLD{Type: "Person", Name: rec.Author}
+	}
+
+	if len(rec.Image) > 0 {
+		jsonLD.Image = append(jsonLD.Image, tmplRecipe.StockImage)
+	}
+
+	for _, line := range rec.Info["preparation"] {
+		jsonLD.RecipeInstructions = append(jsonLD.RecipeInstructions, &HowToStepJSONLD{Type: "HowToStep", Text: line})
+	}
+
+	if !rec.Created.IsZero() {
+		jsonLD.DateCreated = rec.Created.Format(time.RFC3339)
+	}
+
+	if !rec.Modified.IsZero() {
+		jsonLD.DateModified = rec.Modified.Format(time.RFC3339)
+	}
+
+	return jsonLD
+}
+
+// isoDuration formats minutes as an ISO 8601 duration, empty when there are none
+func isoDuration(minutes float64) string {
+	total := int(math.Round(minutes))
+	if total <= 0 {
+		return ""
+	}
+
+	duration := "PT"
+	if total >= 60 {
+		duration += fmt.Sprintf("%dH", total/60)
+	}
+
+	if total%60 != 0 {
+		duration += fmt.Sprintf("%dM", total%60)
+	}
+
+	return duration
+}
+
+// recipeJSONLDScript is the JSON-LD of the recipe for a script element
+// json.Marshal escapes <, > and &, so the recipe can not end the script early
+func recipeJSONLDScript(rec *recipe.Recipe, tmplRecipe *TemplateRecipe) (template.JS, error) {
+	data, err := json.Marshal(newRecipeJSONLD(rec, tmplRecipe))
+	if err != nil {
+		return "", err
+	}
+
+	return template.JS(data), nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsoDuration(t *testing.T) {
+	for minutes, expected := range map[float64]string{
+		0:    "",
+		-5:   "",
+		45:   "PT45M",
+		60:   "PT1H",
+		90:   "PT1H30M",
+		29.6: "PT30M",
+	} {
+		if duration := isoDuration(minutes); duration != expected {
+			t.Errorf("Expected \"%s\" for %v minutes, got \"%s\"", expected, minutes, duration)
+		}
+	}
+}
+
+func TestRecipeJSONLD(t *testing.T) {
+	handler := newTestHandler(t)
+	templates, err := NewTemplate(nil)
+	if err != nil {
+		t.Fatal("Failed to parse templates", err)
+	}
+
+	handler.templates = templates
+	rec, _ := handler.getRecipe("Banana Bread")
+	rec.Author = "Grandma </script><b>"
+	rec.Info["serves"] = []string{"8 slices"}
+
+	recorder := httptest.NewRecorder()
+	handler.Recipe(recorder, httptest.NewRequest(http.MethodGet, "/recipe/Banana%20Bread", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", recorder.Code)
+	}
+
+	body := recorder.Body.String()
+	start := strings.Index(body, `<script type="application/ld+json">`)
+	if start < 0 {
+		t.Fatal("Expected JSON-LD in the recipe page")
+	}
+
+	body = body[start+len(`<script type="application/ld+json">`):]
+	end := strings.Index(body, "</script>")
+	if end < 0 {
+		t.Fatal("Expected the JSON-LD script to end")
+	}
+
+	jsonLD := &RecipeJSONLD{}
+	err = json.Unmarshal([]byte(body[:end]), jsonLD)
+	if err != nil {
+		t.Fatal("Failed to decode JSON-LD", err, body[:end])
+	}
+
+	if jsonLD.Type != "Recipe" || jsonLD.Name != "Banana Bread" {
+		t.Errorf("Expected the Recipe Banana Bread, got %s %s", jsonLD.Type, jsonLD.Name)
+	}
+
+	// the author can not end the script early
+	if jsonLD.Author == nil || jsonLD.Author.Name != rec.Author {
+		t.Errorf("Expected author %q, got %+v", rec.Author, jsonLD.Author)
+	}
+
+	if strings.Join(jsonLD.RecipeIngredient, "|") != "3 ripe bananas|2 cups flour|2 eggs" {
+		t.Errorf("Expected the ingredients, got %v", jsonLD.RecipeIngredient)
+	}
+
+	if len(jsonLD.RecipeInstructions) != 1 || jsonLD.RecipeInstructions[0].Text != "Mix and bake at 350 degrees for 60 minutes" {
+		t.Errorf("Expected the preparation as steps, got %+v", jsonLD.RecipeInstructions)
+	}
+
+	if len(jsonLD.RecipeYield) != 1 || jsonLD.RecipeYield[0] != "8 slices" {
+		t.Errorf("Expected a yield of 8 slices, got %v", jsonLD.RecipeYield)
+	}
+
+	if jsonLD.TotalTime != "PT1H" {
+		t.Errorf("Expected a total time of PT1H, got \"%s\"", jsonLD.TotalTime)
+	}
+
+	if jsonLD.RecipeCategory != "Baking" || jsonLD.Keywords != "Bread" {
+		t.Errorf("Expected category Baking with keyword Bread, got %s and %s", jsonLD.RecipeCategory, jsonLD.Keywords)
+	}
+
+	// Banana Bread has no stock image
+	if len(jsonLD.Image) != 0 {
+		t.Errorf("Expected no image, got %v", jsonLD.Image)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	open "github.com/skratchdot/open-golang/open"
 	flag "github.com/spf13/pflag"
+	"github.com/tblyler/recipe-card/recipe"
 )
 
 // Mux is the http servemux for this server
@@ -66,6 +67,14 @@ func main() {
 	debug := false
 	listenAddr := "127.0.0.1"
 	listenPort := uint16(0)
+	synonymsPath := ""
+	exportTitle := ""
+	exportScans := false
+	outputPath := ""
+	cookbookTitle := ""
+	cookbookSelection := CookbookSelection{}
+	importPaths := []string{}
+	importCategory := ""
 	indexPath := filepath.Join(path.Dir(recipePath), "search_idx")
 	recipePath = filepath.Join(path.Dir(recipePath), "Recipes")
 	recipePath, err = filepath.Abs(recipePath)
@@ -82,24 +91,45 @@ func main() {
 	flag.StringVarP(&listenAddr, "host", "h", listenAddr, "HTTP listen address")
 	flag.Uint16VarP(&listenPort, "port", "p", listenPort, "HTTP listen port")
 	flag.StringVarP(&recipePath, "recipes", "r", recipePath, "Path to recipes")
-	flag.StringVarP(&indexPath, "index", "i", indexPath, "Path for search index")
+	flag.StringVarP(&indexPath, "index", "i", indexPath, "Path for search index and image thumbnail cache")
+	flag.StringVarP(&synonymsPath, "synonyms", "s", synonymsPath, "Path to extra search synonyms, one comma separated group per line")
 	flag.BoolVarP(&debug, "debug", "d", debug, "Enable debug mode")
+	flag.StringVar(&exportTitle, "pdf", exportTitle, "Write the recipe with this title as a PDF and exit")
+	flag.BoolVar(&exportScans, "pdf-scans", exportScans, "Add the recipe's scans to the PDF")
+	flag.StringVar(&cookbookTitle, "cookbook", cookbookTitle, "Compile the recipes into a cookbook with this title and exit, the output ending in .pdf or .epub")
+	flag.StringVar(&cookbookSelection.Category, "cookbook-category", "", "Only put recipes in this category in the cookbook")
+	flag.StringVar(&cookbookSelection.Tag, "cookbook-tag", "", "Only put recipes with this tag in the cookbook")
+	flag.StringVar(&cookbookSelection.Query, "cookbook-query", "", "Only put recipes matching this search in the cookbook")
+	flag.StringArrayVar(&importPaths, "import", importPaths, "Import a recipe from a saved web page with schema.org recipe data and exit, can be given more than once")
+	flag.StringVar(&importCategory, "import-category", importCategory, "Category to put imported recipes in, folders separated by /")
+	flag.StringVarP(&outputPath, "output", "o", outputPath, "Path of the PDF or cookbook, the title by default")
 	flag.Parse()
 
 	if debug {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	if exportTitle != "" {
+		err = exportRecipePDF(recipePath, exportTitle, outputPath, exportScans)
+		if err != nil {
+			log.WithError(err).WithField("title", exportTitle).Errorln("Failed to write PDF")
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	log.WithFields(log.Fields{
-		"host":    listenAddr,
-		"port":    listenPort,
-		"recipes": recipePath,
-		"index":   indexPath,
-		"debug":   debug,
+		"host":     listenAddr,
+		"port":     listenPort,
+		"recipes":  recipePath,
+		"index":    indexPath,
+		"synonyms": synonymsPath,
+		"debug":    debug,
 	}).Debugln("Options received")
 
 	log.Debugln("Creating new handler")
-	handler, err := NewHandler(recipePath, indexPath, log.StandardLogger())
+	handler, err := NewHandler(recipePath, indexPath, synonymsPath, log.StandardLogger())
 	if err != nil {
 		log.WithError(err).Errorln("Failed to create new handler")
 		os.Exit(1)
@@ -107,6 +137,32 @@ func main() {
 
 	log.Debugln("Successfully created new handler")
 
+	if len(importPaths) > 0 {
+		failed := handler.importRecipeFiles(importPaths, importCategory)
+		handler.Close()
+		if failed > 0 {
+			log.Errorf("Failed to import %d of %d pages", failed, len(importPaths))
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if cookbookTitle != "" {
+		if outputPath == "" {
+			outputPath = recipe.FileName(cookbookTitle) + ".pdf"
+		}
+
+		err = handler.compileCookbook(cookbookTitle, cookbookSelection, outputPath)
+		handler.Close()
+		if err != nil {
+			log.WithError(err).WithField("title", cookbookTitle).Errorln("Failed to compile cookbook")
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	defer handler.Close()
 
 	log.Debugln("Creating TCP listening port")
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"net/http"
+)
+
+const (
+	apiOpenAPIPattern = "/api/openapi.json"
+
+	// openAPIDocument describes the JSON API, openapi_test.go checks it against the handlers
+	openAPIDocument = `{
+	"openapi": "3.0.3",
+	"info": {
+		"title": "Recipe Card",
+		"description": "Search and browse recipe cards",
+		"version": "1.0.0"
+	},
+	"paths": {
+		"/api/v1/recipes": {
+			"get": {
+				"operationId": "listRecipes",
+				"summary": "Page of recipes, filtered and sorted like /recipes/",
+				"parameters": [
+					{"$ref": "#/components/parameters/page"},
+					{"$ref": "#/components/parameters/perPage"},
+					{"name": "category", "in": "query", "description": "Folders between the recipes path and the recipe", "schema": {"type": "string"}},
+					{"name": "tag", "in": "query", "description": "Lowercase tag", "schema": {"type": "string"}},
+					{"name": "author", "in": "query", "schema": {"type": "string"}},
+					{"name": "lang", "in": "query", "description": "ISO 639-1 language code", "schema": {"type": "string"}},
+					{"name": "oven", "in": "query", "description": "Oven temperature range", "schema": {"type": "string", "enum": ["Under 300°F", "300°F to 349°F", "350°F to 399°F", "400°F and up"]}},
+					{"name": "time", "in": "query", "description": "Total time range", "schema": {"type": "string", "enum": ["Under 30 minutes", "30 to 59 minutes", "1 to 2 hours", "Over 2 hours"]}},
+					{"name": "sort", "in": "query", "schema": {"type": "string", "enum": ["title", "modified", "added"], "default": "title"}}
+				],
+				"responses": {
+					"200": {"description": "Page of recipes", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/RecipeList"}}}},
+					"400": {"$ref": "#/components/responses/BadRequest"}
+				}
+			}
+		},
+		"/api/v1/recipes/{id}": {
+			"get": {
+				"operationId": "getRecipe",
+				"summary": "A single recipe",
+				"parameters": [{"$ref": "#/components/parameters/id"}],
+				"responses": {
+					"200": {"description": "The recipe", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Recipe"}}}},
+					"404": {"$ref": "#/components/responses/NotFound"}
+				}
+			}
+		},
+		"/api/v1/recipes/{id}/images": {
+			"get": {
+				"operationId": "getRecipeImages",
+				"summary": "Image URLs of a recipe",
+				"parameters": [{"$ref": "#/components/parameters/id"}],
+				"responses": {
+					"200": {"description": "The recipe's images", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Images"}}}},
+					"404": {"$ref": "#/components/responses/NotFound"}
+				}
+			}
+		},
+		"/api/v1/search": {
+			"get": {
+				"operationId": "searchRecipes",
+				"summary": "Page of search results, using the same syntax as the search box",
+				"parameters": [
+					{"name": "q", "in": "query", "required": true, "schema": {"type": "string"}, "example": "ingredients:flour"},
+					{"$ref": "#/components/parameters/page"},
+					{"$ref": "#/components/parameters/perPage"},
+					{"name": "lang", "in": "query", "description": "ISO 639-1 language code", "schema": {"type": "string"}}
+				],
+				"responses": {
+					"200": {"description": "Page of search results", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/SearchResults"}}}},
+					"400": {"$ref": "#/components/responses/BadRequest"}
+				}
+			}
+		},
+		"/api/pantry": {
+			"get": {
+				"operationId": "pantryMatches",
+				"summary": "Recipes that can be made with some of the pantry items, best matches first",
+				"parameters": [
+					{"name": "ingredients", "in": "query", "required": true, "description": "Comma, semicolon or newline separated items", "schema": {"type": "string"}, "example": "flour, eggs"}
+				],
+				"responses": {
+					"200": {"description": "Matching recipes", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/PantryMatch"}}}}},
+					"400": {"$ref": "#/components/responses/BadRequest"}
+				}
+			}
+		},
+		"/api/suggest": {
+			"get": {
+				"operationId": "suggest",
+				"summary": "Recipe titles and ingredients starting with the typed words",
+				"parameters": [
+					{"name": "q", "in": "query", "required": true, "schema": {"type": "string"}, "example": "ban"},
+					{"name": "limit", "in": "query", "description": "Most suggestions of each kind", "schema": {"type": "integer", "minimum": 1, "maximum": 8, "default": 8}}
+				],
+				"responses": {
+					"200": {"description": "Suggestions", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Suggestion"}}}}},
+					"400": {"$ref": "#/components/responses/BadRequest"}
+				}
+			}
+		}
+	},
+	"components": {
+		"parameters": {
+			"id": {"name": "id", "in": "path", "required": true, "description": "Title of the recipe", "schema": {"type": "string"}},
+			"page": {"name": "page", "in": "query", "description": "One based page number, at most 2147483647 divided by per_page", "schema": {"type": "integer", "minimum": 1, "default": 1}},
+			"perPage": {"name": "per_page", "in": "query", "description": "Results in a page", "schema": {"type": "integer", "minimum": 1, "maximum": 100}}
+		},
+		"responses": {
+			"BadRequest": {"description": "Invalid parameters", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}},
+			"NotFound": {"description": "No such recipe", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+		},
+		"schemas": {
+			"Error": {
+				"type": "object",
+				"required": ["error"],
+				"properties": {
+					"error": {"type": "string"}
+				}
+			},
+			"Recipe": {
+				"type": "object",
+				"required": ["id", "url", "page_url", "title", "info", "docx_url", "scan_urls", "attachments", "tags", "category", "author", "language", "created", "modified"],
+				"properties": {
+					"id": {"type": "string", "description": "Title of the recipe, used to look it up"},
+					"url": {"type": "string", "description": "Relative URL to the recipe in the API"},
+					"page_url": {"type": "string", "description": "Relative URL to the recipe page"},
+					"title": {"type": "string"},
+					"info": {"type": "object", "description": "Lines of each section, such as ingredients", "additionalProperties": {"type": "array", "items": {"type": "string"}}},
+					"docx_url": {"type": "string", "description": "Relative URL to download the docx"},
+					"scan_urls": {"type": "array", "items": {"type": "string"}, "description": "Relative URLs to the scanned cards"},
+					"attachments": {"type": "array", "items": {"$ref": "#/components/schemas/Attachment"}},
+					"tags": {"type": "array", "nullable": true, "items": {"type": "string"}},
+					"category": {"type": "string"},
+					"author": {"type": "string"},
+					"language": {"type": "string"},
+					"created": {"type": "string", "format": "date-time"},
+					"modified": {"type": "string", "format": "date-time"}
+				}
+			},
+			"Attachment": {
+				"type": "object",
+				"required": ["name", "url", "pages"],
+				"properties": {
+					"name": {"type": "string"},
+					"url": {"type": "string", "description": "Relative URL to download the document"},
+					"pages": {"type": "integer", "description": "Number of pages, 0 if they could not be counted"}
+				}
+			},
+			"SearchResult": {
+				"allOf": [
+					{"$ref": "#/components/schemas/Recipe"},
+					{
+						"type": "object",
+						"required": ["score"],
+						"properties": {
+							"score": {"type": "number"}
+						}
+					}
+				]
+			},
+			"RecipeList": {
+				"type": "object",
+				"required": ["recipes", "total", "page", "per_page"],
+				"properties": {
+					"recipes": {"type": "array", "items": {"$ref": "#/components/schemas/Recipe"}},
+					"total": {"type": "integer"},
+					"page": {"type": "integer"},
+					"per_page": {"type": "integer"}
+				}
+			},
+			"SearchResults": {
+				"type": "object",
+				"required": ["query", "results", "total", "page", "per_page"],
+				"properties": {
+					"query": {"type": "string"},
+					"results": {"type": "array", "items": {"$ref": "#/components/schemas/SearchResult"}},
+					"total": {"type": "integer"},
+					"page": {"type": "integer"},
+					"per_page": {"type": "integer"}
+				}
+			},
+			"Images": {
+				"type": "object",
+				"required": ["stock_image", "scans"],
+				"properties": {
+					"stock_image": {"type": "string", "description": "Relative URL to the image in the docx"},
+					"scans": {"type": "array", "items": {"type": "string"}, "description": "Relative URLs to the scanned cards"}
+				}
+			},
+			"PantryMatch": {
+				"type": "object",
+				"required": ["id", "url", "have", "missing", "score"],
+				"properties": {
+					"id": {"type": "string"},
+					"url": {"type": "string"},
+					"have": {"type": "array", "items": {"type": "string"}},
+					"missing": {"type": "array", "items": {"type": "string"}},
+					"score": {"type": "number", "description": "Fraction of the ingredients on hand"}
+				}
+			},
+			"Suggestion": {
+				"type": "object",
+				"required": ["text", "kind"],
+				"properties": {
+					"text": {"type": "string"},
+					"kind": {"type": "string", "enum": ["recipe", "ingredient"]},
+					"url": {"type": "string"}
+				}
+			}
+		}
+	}
+}
+`
+)
+
+// APIOpenAPI handles the OpenAPI description of the JSON API
+func (h *Handler) APIOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(openAPIDocument))
+}
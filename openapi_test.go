@@ -0,0 +1,444 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve"
+	log "github.com/sirupsen/logrus"
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+// newTestHandler creates a handler with an in memory index of a few recipes
+func newTestHandler(t *testing.T) *Handler {
+	synonyms, err := LoadSynonyms("")
+	if err != nil {
+		t.Fatal("Failed to load synonyms", err)
+	}
+
+	indexMapping, err := NewIndexMapping(synonyms)
+	if err != nil {
+		t.Fatal("Failed to create index mapping", err)
+	}
+
+	idx, err := bleve.NewMemOnly(indexMapping)
+	if err != nil {
+		t.Fatal("Failed to create index", err)
+	}
+
+	recipePath := filepath.Join(string(filepath.Separator), "recipes")
+	handler := &Handler{
+		recipePath: recipePath,
+		recipes:    make(map[string]*recipe.Recipe),
+		idx:        idx,
+		logger:     log.New(),
+	}
+
+	for _, rec := range []*recipe.Recipe{
+		{
+			Title:     "Banana Bread",
+			Category:  "Baking",
+			Language:  "en",
+			Tags:      []string{"Bread"},
+			DocxPath:  filepath.Join(recipePath, "Baking", "Banana Bread", "banana bread.docx"),
+			ScanPaths: []string{filepath.Join(recipePath, "Baking", "Banana Bread", "front.jpg")},
+			Attachments: []*recipe.Attachment{
+				{Path: filepath.Join(recipePath, "Baking", "Banana Bread", "card.pdf"), Pages: 2},
+			},
+			Info: map[string][]string{
+				"ingredients": {"3 ripe bananas", "2 cups flour", "2 eggs"},
+				"preparation": {"Mix and bake at 350 degrees for 60 minutes"},
+			},
+		},
+		{
+			Title:    "Pancakes",
+			Language: "en",
+			DocxPath: filepath.Join(recipePath, "Pancakes", "pancakes.docx"),
+			Info: map[string][]string{
+				"ingredients": {"1 cup flour", "1 egg", "1 cup milk"},
+				"preparation": {"Fry in a hot skillet"},
+			},
+		},
+	} {
+		handler.recipes[rec.Title] = rec
+		handler.recipeSlice = append(handler.recipeSlice, rec)
+		err = idx.Index(rec.Title, newRecipeDocument(rec))
+		if err != nil {
+			t.Fatal("Failed to index", rec.Title, err)
+		}
+	}
+
+	return handler
+}
+
+func loadOpenAPIDocument(t *testing.T) map[string]interface{} {
+	document := make(map[string]interface{})
+	err := json.Unmarshal([]byte(openAPIDocument), &document)
+	if err != nil {
+		t.Fatal("Failed to parse the OpenAPI document", err)
+	}
+
+	return document
+}
+
+// resolveRef follows a local $ref in the OpenAPI document
+func resolveRef(document map[string]interface{}, value map[string]interface{}) (map[string]interface{}, error) {
+	ref, ok := value["$ref"].(string)
+	if !ok {
+		return value, nil
+	}
+
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("only local references are supported, got %s", ref)
+	}
+
+	var current interface{} = document
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s does not resolve", ref)
+		}
+
+		current, ok = object[key]
+		if !ok {
+			return nil, fmt.Errorf("%s does not resolve", ref)
+		}
+	}
+
+	object, ok := current.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not an object", ref)
+	}
+
+	return resolveRef(document, object)
+}
+
+// validateSchema checks value against the subset of JSON schema used by the OpenAPI document
+// objects may not have properties that are not documented
+func validateSchema(document map[string]interface{}, schema map[string]interface{}, value interface{}, location string) []error {
+	schema, err := resolveRef(document, schema)
+	if err != nil {
+		return []error{err}
+	}
+
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable {
+			return nil
+		}
+
+		return []error{fmt.Errorf("%s must not be null", location)}
+	}
+
+	// merge allOf into a single object schema
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		merged := map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+			"required":   []interface{}{},
+		}
+
+		for _, part := range allOf {
+			partSchema, err := resolveRef(document, part.(map[string]interface{}))
+			if err != nil {
+				return []error{err}
+			}
+
+			for name, property := range partSchema["properties"].(map[string]interface{}) {
+				merged["properties"].(map[string]interface{})[name] = property
+			}
+
+			if required, ok := partSchema["required"].([]interface{}); ok {
+				merged["required"] = append(merged["required"].([]interface{}), required...)
+			}
+		}
+
+		schema = merged
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if allowed == value {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return []error{fmt.Errorf("%s is %v, not one of %v", location, value, enum)}
+		}
+	}
+
+	errs := []error{}
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return []error{fmt.Errorf("%s must be an object", location)}
+		}
+
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, exists := object[name.(string)]; !exists {
+					errs = append(errs, fmt.Errorf("%s is missing %s", location, name))
+				}
+			}
+		}
+
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for name, property := range object {
+			propertySchema, documented := properties[name].(map[string]interface{})
+			if !documented {
+				propertySchema = additional
+			}
+
+			if propertySchema == nil {
+				errs = append(errs, fmt.Errorf("%s has undocumented property %s", location, name))
+				continue
+			}
+
+			errs = append(errs, validateSchema(document, propertySchema, property, location+"."+name)...)
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return []error{fmt.Errorf("%s must be an array", location)}
+		}
+
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range array {
+			errs = append(errs, validateSchema(document, items, item, location+"["+strconv.Itoa(i)+"]")...)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			errs = append(errs, fmt.Errorf("%s must be a string", location))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			errs = append(errs, fmt.Errorf("%s must be a number", location))
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			errs = append(errs, fmt.Errorf("%s must be an integer", location))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			errs = append(errs, fmt.Errorf("%s must be a boolean", location))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("%s has unsupported schema type %v", location, schema["type"]))
+	}
+
+	return errs
+}
+
+// documentedPath finds the OpenAPI path template matching the URL path
+func documentedPath(document map[string]interface{}, urlPath string) string {
+	parts := strings.Split(urlPath, "/")
+	for path := range document["paths"].(map[string]interface{}) {
+		templateParts := strings.Split(path, "/")
+		if len(templateParts) != len(parts) {
+			continue
+		}
+
+		matches := true
+		for i, templatePart := range templateParts {
+			if templatePart != parts[i] && !strings.HasPrefix(templatePart, "{") {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return path
+		}
+	}
+
+	return ""
+}
+
+func newTestMux(handler *Handler) *http.ServeMux {
+	mux := http.NewServeMux()
+	for pattern, handlerFunc := range handler.GetHandlerFuncs() {
+		mux.HandleFunc(pattern, handlerFunc)
+	}
+
+	return mux
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	document := loadOpenAPIDocument(t)
+
+	if version, _ := document["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		t.Errorf("Expected an OpenAPI 3 document, got version %v", document["openapi"])
+	}
+
+	var checkRefs func(value interface{})
+	checkRefs = func(value interface{}) {
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			if _, err := resolveRef(document, typed); err != nil {
+				t.Error(err)
+			}
+
+			for _, child := range typed {
+				checkRefs(child)
+			}
+		case []interface{}:
+			for _, child := range typed {
+				checkRefs(child)
+			}
+		}
+	}
+
+	checkRefs(document)
+
+	handler := &Handler{}
+	recorder := httptest.NewRecorder()
+	handler.APIOpenAPI(recorder, httptest.NewRequest(http.MethodGet, apiOpenAPIPattern, nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected application/json, got %s", contentType)
+	}
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	document := loadOpenAPIDocument(t)
+	handler := newTestHandler(t)
+	mux := newTestMux(handler)
+
+	documentedPatterns := make(map[string]bool)
+	for path := range document["paths"].(map[string]interface{}) {
+		_, pattern := mux.Handler(httptest.NewRequest(http.MethodGet, strings.Replace(path, "{id}", "id", -1), nil))
+		if !strings.HasPrefix(pattern, "/api/") {
+			t.Errorf("Documented path %s is served by %s instead of an API handler", path, pattern)
+			continue
+		}
+
+		documentedPatterns[pattern] = true
+	}
+
+	for pattern := range handler.GetHandlerFuncs() {
+		if !strings.HasPrefix(pattern, "/api/") || pattern == apiOpenAPIPattern {
+			continue
+		}
+
+		if !documentedPatterns[pattern] {
+			t.Errorf("API handler %s has no documented paths", pattern)
+		}
+	}
+}
+
+func TestOpenAPIResponses(t *testing.T) {
+	document := loadOpenAPIDocument(t)
+	handler := newTestHandler(t)
+	mux := newTestMux(handler)
+
+	requests := []struct {
+		url    string
+		status int
+	}{
+		{"/api/v1/recipes", http.StatusOK},
+		{"/api/v1/recipes?category=Baking&sort=added", http.StatusOK},
+		{"/api/v1/recipes?per_page=1&page=2", http.StatusOK},
+		{"/api/v1/recipes?per_page=0", http.StatusBadRequest},
+		{"/api/v1/recipes?page=9223372036854775807", http.StatusBadRequest},
+		{"/api/v1/recipes?page=768614336404564651", http.StatusBadRequest},
+		{"/api/v1/recipes?oven=hot", http.StatusBadRequest},
+		{"/api/v1/recipes/" + url.PathEscape("Banana Bread"), http.StatusOK},
+		{"/api/v1/recipes/Pancakes", http.StatusOK},
+		{"/api/v1/recipes/Waffles", http.StatusNotFound},
+		{"/api/v1/recipes/" + url.PathEscape("Banana Bread") + "/images", http.StatusOK},
+		{"/api/v1/recipes/Waffles/images", http.StatusNotFound},
+		{"/api/v1/search?q=flour", http.StatusOK},
+		{"/api/v1/search?q=" + url.QueryEscape("ingredients:bananas"), http.StatusOK},
+		{"/api/v1/search?q=" + url.QueryEscape("colour:red"), http.StatusOK},
+		{"/api/v1/search?q=" + url.QueryEscape(`title:"pound cake`), http.StatusBadRequest},
+		{"/api/v1/search", http.StatusBadRequest},
+		{"/api/v1/search?q=flour&page=9223372036854775807", http.StatusBadRequest},
+		{"/api/pantry?ingredients=" + url.QueryEscape("flour, eggs"), http.StatusOK},
+		{"/api/pantry", http.StatusBadRequest},
+		{"/api/suggest?q=ban", http.StatusOK},
+		{"/api/suggest?q=ban&limit=100", http.StatusBadRequest},
+	}
+
+	testedOperations := make(map[string]bool)
+	for _, request := range requests {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, request.url, nil))
+
+		if recorder.Code != request.status {
+			t.Errorf("Expected status %d for %s, got %d: %s", request.status, request.url, recorder.Code, recorder.Body.String())
+			continue
+		}
+
+		requestURL, _ := url.Parse(request.url)
+		path := documentedPath(document, requestURL.Path)
+		if path == "" {
+			t.Errorf("%s is not documented", request.url)
+			continue
+		}
+
+		operation, ok := document["paths"].(map[string]interface{})[path].(map[string]interface{})["get"].(map[string]interface{})
+		if !ok {
+			t.Errorf("GET %s is not documented", path)
+			continue
+		}
+
+		testedOperations[path] = true
+		response, ok := operation["responses"].(map[string]interface{})[strconv.Itoa(recorder.Code)].(map[string]interface{})
+		if !ok {
+			t.Errorf("Status %d of GET %s is not documented", recorder.Code, path)
+			continue
+		}
+
+		response, err := resolveRef(document, response)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("Expected application/json for %s, got %s", request.url, contentType)
+		}
+
+		schema := response["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+
+		var body interface{}
+		err = json.Unmarshal(recorder.Body.Bytes(), &body)
+		if err != nil {
+			t.Errorf("Failed to parse the response of %s: %v", request.url, err)
+			continue
+		}
+
+		for _, err := range validateSchema(document, schema, body, request.url) {
+			t.Error(err)
+		}
+	}
+
+	untested := []string{}
+	for path := range document["paths"].(map[string]interface{}) {
+		if !testedOperations[path] {
+			untested = append(untested, path)
+		}
+	}
+
+	sort.Strings(untested)
+	if len(untested) > 0 {
+		t.Errorf("Documented paths without a request in this test: %s", strings.Join(untested, ", "))
+	}
+}
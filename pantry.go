@@ -0,0 +1,255 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/search/query"
+)
+
+const (
+	pantryPattern    = "/pantry/"
+	apiPantryPattern = "/api/pantry"
+)
+
+// PantryMatch is a recipe that can be made with some of the pantry items
+type PantryMatch struct {
+	// title of the recipe
+	ID string `json:"id"`
+	// relative URL to the recipe page
+	URL string `json:"url"`
+	// ingredient lines that are on hand
+	Have []string `json:"have"`
+	// ingredient lines that are not on hand
+	Missing []string `json:"missing"`
+	// fraction of the ingredients that are on hand
+	Score float64 `json:"score"`
+}
+
+// parsePantry splits comma, semicolon or newline separated pantry items
+func parsePantry(text string) (items []string) {
+	seen := make(map[string]bool)
+	for _, item := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n' || r == '\r'
+	}) {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" || seen[item] {
+			continue
+		}
+
+		seen[item] = true
+		items = append(items, item)
+	}
+
+	return
+}
+
+// pantryAnalyzer is the analyzer ingredient names of recipes in language are indexed with
+func (h *Handler) pantryAnalyzer(language string) *analysis.Analyzer {
+	analyzer, exists := languageAnalyzers[language]
+	if !exists {
+		analyzer = cookingAnalyzer
+	}
+
+	return h.idx.Mapping().AnalyzerNamed(analyzer)
+}
+
+// analyzedTerms are the terms text is indexed as
+func analyzedTerms(analyzer *analysis.Analyzer, text string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, token := range analyzer.Analyze([]byte(text)) {
+		terms[string(token.Term)] = true
+	}
+
+	return terms
+}
+
+// analyzedMatch checks if every word of an analyzed pantry item is in the terms of an ingredient,
+// either as itself or through a synonym, which also covers the words of the phrase it replaces
+func analyzedMatch(item analysis.TokenStream, terms map[string]bool) bool {
+	if len(item) == 0 {
+		return false
+	}
+
+	covered := 0
+	for start := 0; start < len(item); {
+		// tokens at the same position are alternatives, such as a word and its synonym
+		end := start
+		for end < len(item) && item[end].Position == item[start].Position {
+			end++
+		}
+
+		if item[start].Start >= covered {
+			found := -1
+			for _, token := range item[start:end] {
+				if terms[string(token.Term)] && token.End > found {
+					found = token.End
+				}
+			}
+
+			if found < 0 {
+				return false
+			}
+
+			covered = found
+		}
+
+		start = end
+	}
+
+	return true
+}
+
+// pantryMatches finds recipes using the pantry items, best matches first
+// items and ingredients are compared with the analyzer of the index, so stems
+// and synonyms that find a recipe also count as having its ingredient
+func (h *Handler) pantryMatches(items []string) ([]*PantryMatch, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	queries := make([]query.Query, 0, len(items))
+	for _, item := range items {
+		itemQuery := bleve.NewMatchQuery(item)
+		itemQuery.SetField("ingredient_names")
+		queries = append(queries, itemQuery)
+	}
+
+	searchResults, err := h.idx.Search(bleve.NewSearchRequestOptions(
+		localizeQuery(bleve.NewDisjunctionQuery(queries...)),
+		h.recipeCount(),
+		0,
+		false,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	// the analyzed items of each language
+	analyzedItems := make(map[string][]analysis.TokenStream)
+	matches := []*PantryMatch{}
+	for _, hit := range searchResults.Hits {
+		rec, exists := h.getRecipe(hit.ID)
+		if !exists {
+			continue
+		}
+
+		analyzer := h.pantryAnalyzer(rec.Language)
+		if analyzer == nil {
+			continue
+		}
+
+		itemTokens, exists := analyzedItems[rec.Language]
+		if !exists {
+			for _, item := range items {
+				itemTokens = append(itemTokens, analyzer.Analyze([]byte(item)))
+			}
+
+			analyzedItems[rec.Language] = itemTokens
+		}
+
+		match := &PantryMatch{
+			ID:      rec.Title,
+			URL:     recipePattern + url.PathEscape(rec.Title),
+			Have:    []string{},
+			Missing: []string{},
+		}
+
+		ingredients := rec.Ingredients()
+		for _, ingredient := range ingredients {
+			terms := analyzedTerms(analyzer, ingredient.Name)
+			onHand := false
+			for _, item := range itemTokens {
+				if analyzedMatch(item, terms) {
+					onHand = true
+					break
+				}
+			}
+
+			if onHand {
+				match.Have = append(match.Have, ingredient.Line)
+			} else {
+				match.Missing = append(match.Missing, ingredient.Line)
+			}
+		}
+
+		// the search finds every recipe sharing a word with an item,
+		// so recipes without a whole item on hand are left out
+		if len(match.Have) == 0 {
+			continue
+		}
+
+		match.Score = float64(len(match.Have)) / float64(len(ingredients))
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+
+		if len(matches[i].Missing) != len(matches[j].Missing) {
+			return len(matches[i].Missing) < len(matches[j].Missing)
+		}
+
+		return matches[i].ID < matches[j].ID
+	})
+
+	return matches, nil
+}
+
+// Pantry handles the "what can I make" page
+func (h *Handler) Pantry(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	pantry := strings.TrimSpace(r.FormValue("ingredients"))
+	tmplData := &TemplateData{
+		PageTitle:   "Recipe Card - What can I make?",
+		PantryValue: pantry,
+	}
+
+	matches, err := h.pantryMatches(parsePantry(pantry))
+	if err != nil {
+		h.logger.WithError(err).WithField("pantry", pantry).Errorln("Failed to search pantry")
+		tmplData.SearchError = "Unable to search right now: " + err.Error()
+	}
+
+	for _, match := range matches {
+		rec, exists := h.getRecipe(match.ID)
+		if !exists {
+			continue
+		}
+
+		tmplRecipe := h.recipeToTemplateRecipe(rec)
+		tmplData.PantryMatches = append(tmplData.PantryMatches, &TemplatePantryMatch{
+			Recipe:  tmplRecipe,
+			Have:    match.Have,
+			Missing: match.Missing,
+			Percent: int(match.Score * 100),
+		})
+	}
+
+	h.templates.ExecuteTemplate(w, "pantry", tmplData)
+}
+
+// APIPantry handles the JSON "what can I make" endpoint
+func (h *Handler) APIPantry(w http.ResponseWriter, r *http.Request) {
+	items := parsePantry(r.FormValue("ingredients"))
+	if len(items) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "ingredients must have at least one item")
+		return
+	}
+
+	matches, err := h.pantryMatches(items)
+	if err != nil {
+		h.logger.WithError(err).WithField("items", items).Errorln("Failed to search pantry")
+		writeJSONError(w, http.StatusInternalServerError, "unable to search")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, matches)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+func newPantryTestHandler(t *testing.T) *Handler {
+	handler := newTestHandler(t)
+	templates, err := NewTemplate(nil)
+	if err != nil {
+		t.Fatal("Failed to parse templates", err)
+	}
+
+	handler.templates = templates
+	recipes := map[string][]string{
+		"Scallion Pancakes": {"2 green onions, chopped", "1 cup flour", "1 egg"},
+		"Scallion Dip":      {"3 scallions", "1 cup sour cream"},
+		"Onion Soup":        {"3 onions", "4 cups broth"},
+	}
+
+	for title, ingredients := range recipes {
+		addTestRecipe(t, handler, &recipe.Recipe{
+			Title:    title,
+			Language: "en",
+			Info:     map[string][]string{"ingredients": ingredients},
+		})
+	}
+
+	return handler
+}
+
+// apiPantry gets the matches for the ingredients from /api/pantry
+func apiPantry(t *testing.T, handler *Handler, ingredients string) []*PantryMatch {
+	recorder := httptest.NewRecorder()
+	handler.APIPantry(recorder, httptest.NewRequest(http.MethodGet, apiPantryPattern+"?ingredients="+url.QueryEscape(ingredients), nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for %s, got %d", ingredients, recorder.Code)
+	}
+
+	matches := []*PantryMatch{}
+	err := json.Unmarshal(recorder.Body.Bytes(), &matches)
+	if err != nil {
+		t.Fatal("Failed to decode matches", err)
+	}
+
+	return matches
+}
+
+func TestAPIPantry(t *testing.T) {
+	handler := newPantryTestHandler(t)
+
+	// the most on hand first, then the fewest missing, then by title
+	matches := apiPantry(t, handler, "Scallions, flour, eggs")
+	expected := []struct {
+		id      string
+		have    string
+		missing string
+	}{
+		{"Scallion Pancakes", "2 green onions, chopped|1 cup flour|1 egg", ""},
+		{"Banana Bread", "2 cups flour|2 eggs", "3 ripe bananas"},
+		{"Pancakes", "1 cup flour|1 egg", "1 cup milk"},
+		{"Scallion Dip", "3 scallions", "1 cup sour cream"},
+	}
+
+	if len(matches) != len(expected) {
+		t.Fatalf("Expected %d matches, got %d", len(expected), len(matches))
+	}
+
+	for i, match := range matches {
+		if match.ID != expected[i].id {
+			t.Errorf("Expected %s at %d, got %s", expected[i].id, i, match.ID)
+			continue
+		}
+
+		if strings.Join(match.Have, "|") != expected[i].have || strings.Join(match.Missing, "|") != expected[i].missing {
+			t.Errorf("Expected %s to have %s and miss %s, got %v and %v", match.ID, expected[i].have, expected[i].missing, match.Have, match.Missing)
+		}
+	}
+
+	if matches[0].Score != 1 || matches[3].Score != 0.5 {
+		t.Errorf("Expected scores of 1 and 0.5, got %f and %f", matches[0].Score, matches[3].Score)
+	}
+
+	// synonyms match both ways, but onions alone are not green onions
+	titles := []string{}
+	for _, match := range apiPantry(t, handler, "green onions") {
+		titles = append(titles, match.ID)
+	}
+
+	if strings.Join(titles, ", ") != "Scallion Dip, Scallion Pancakes" {
+		t.Errorf("Expected the recipes with green onions or scallions, got %v", titles)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.APIPantry(recorder, httptest.NewRequest(http.MethodGet, apiPantryPattern+"?ingredients=+,+", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without ingredients, got %d", recorder.Code)
+	}
+}
+
+func TestPantry(t *testing.T) {
+	handler := newPantryTestHandler(t)
+
+	recorder := httptest.NewRecorder()
+	handler.Pantry(recorder, httptest.NewRequest(http.MethodGet, pantryPattern+"?ingredients="+url.QueryEscape("scallions\nsour cream"), nil))
+	body := recorder.Body.String()
+
+	dip := strings.Index(body, "Scallion Dip <small>100% on hand</small>")
+	pancakes := strings.Index(body, "Scallion Pancakes <small>33% on hand</small>")
+	if dip < 0 || pancakes < dip {
+		t.Fatal("Expected Scallion Dip and then Scallion Pancakes")
+	}
+
+	if strings.Contains(body, "Onion Soup") {
+		t.Error("Expected no Onion Soup for scallions")
+	}
+
+	if !strings.Contains(body[pancakes:], "<li>1 cup flour</li>") {
+		t.Error("Expected flour to be missing from Scallion Pancakes")
+	}
+}
@@ -0,0 +1,173 @@
+package pdf
+
+import (
+	"strings"
+)
+
+const (
+	// defaultCharWidth is used for characters that are not in the width tables
+	defaultCharWidth = 556
+
+	// replacementChar is written for characters WinAnsiEncoding does not have
+	replacementChar = '?'
+)
+
+// charWidths are the widths of the printable ASCII characters, starting at the space,
+// in thousandths of the font size from the Adobe font metrics of each font
+var charWidths = [...][95]int{
+	Regular: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	Bold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// extraWidths are the widths of common characters outside of ASCII, the same in both fonts
+var extraWidths = map[byte]int{
+	0x85: 1000, // ellipsis
+	0x91: 222,  // left single quote
+	0x92: 222,  // right single quote
+	0x93: 333,  // left double quote
+	0x94: 333,  // right double quote
+	0x95: 350,  // bullet
+	0x96: 556,  // en dash
+	0x97: 1000, // em dash
+	0xa0: 278,  // no-break space
+	0xb0: 400,  // degree
+	0xbc: 834,  // one quarter
+	0xbd: 834,  // one half
+	0xbe: 834,  // three quarters
+	0xd7: 584,  // multiplication
+}
+
+// winAnsiExtras maps the characters of WinAnsiEncoding from 0x80 to 0x9f, the rest of
+// 0xa0 to 0xff is the same as unicode
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80,
+	'‚': 0x82,
+	'ƒ': 0x83,
+	'„': 0x84,
+	'…': 0x85,
+	'†': 0x86,
+	'‡': 0x87,
+	'ˆ': 0x88,
+	'‰': 0x89,
+	'Š': 0x8a,
+	'‹': 0x8b,
+	'Œ': 0x8c,
+	'Ž': 0x8e,
+	'‘': 0x91,
+	'’': 0x92,
+	'“': 0x93,
+	'”': 0x94,
+	'•': 0x95,
+	'–': 0x96,
+	'—': 0x97,
+	'˜': 0x98,
+	'™': 0x99,
+	'š': 0x9a,
+	'›': 0x9b,
+	'œ': 0x9c,
+	'ž': 0x9e,
+	'Ÿ': 0x9f,
+}
+
+// unicodeFractions are written out for the fractions WinAnsiEncoding does not have
+var unicodeFractions = map[rune]string{
+	'⅓': "1/3",
+	'⅔': "2/3",
+	'⅛': "1/8",
+	'⅜': "3/8",
+	'⅝': "5/8",
+	'⅞': "7/8",
+}
+
+// encodeWinAnsi converts text to the single byte encoding of the standard fonts
+func encodeWinAnsi(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, char := range text {
+		switch {
+		case char == '\t' || char == '\n' || char == '\r':
+			encoded = append(encoded, ' ')
+		case char < 0x20:
+			continue
+		case char < 0x7f || (char >= 0xa0 && char <= 0xff):
+			encoded = append(encoded, byte(char))
+		case winAnsiExtras[char] != 0:
+			encoded = append(encoded, winAnsiExtras[char])
+		case unicodeFractions[char] != "":
+			encoded = append(encoded, unicodeFractions[char]...)
+		default:
+			encoded = append(encoded, replacementChar)
+		}
+	}
+
+	return encoded
+}
+
+// TextWidth is the width of text in the font at size, in points
+func TextWidth(text string, font Font, size float64) float64 {
+	total := 0
+	for _, char := range encodeWinAnsi(text) {
+		switch {
+		case char >= 0x20 && char < 0x7f:
+			total += charWidths[font][char-0x20]
+		case extraWidths[char] != 0:
+			total += extraWidths[char]
+		default:
+			total += defaultCharWidth
+		}
+	}
+
+	return float64(total) * size / 1000
+}
+
+// WrapText splits text into lines no wider than width, breaking between words
+// words that are wider than a line on their own are broken between characters
+func WrapText(text string, font Font, size float64, width float64) []string {
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+
+		if TextWidth(candidate, font, size) <= width {
+			line = candidate
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		// break words too long for a line
+		line = ""
+		for _, char := range word {
+			if line != "" && TextWidth(line+string(char), font, size) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+
+			line += string(char)
+		}
+	}
+
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
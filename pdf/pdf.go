@@ -0,0 +1,401 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// PageWidth of the US letter pages written, in points
+	PageWidth = 612.0
+
+	// PageHeight of the US letter pages written, in points
+	PageHeight = 792.0
+
+	// Margin around the content of every page, in points
+	Margin = 54.0
+
+	// lineSpacing is the height of a line as a multiple of the font size
+	lineSpacing = 1.3
+)
+
+// Font is one of the standard fonts every PDF reader has, so nothing is embedded
+type Font int
+
+const (
+	// Regular is Helvetica
+	Regular Font = iota
+	// Bold is Helvetica-Bold
+	Bold
+)
+
+// fontNames are the base fonts of each Font
+var fontNames = [...]string{
+	Regular: "Helvetica",
+	Bold:    "Helvetica-Bold",
+}
+
+// Info is the metadata of a document
+type Info struct {
+	Title   string
+	Author  string
+	Subject string
+	// keywords separated by commas
+	Keywords string
+	// the time the document was written is used when it is zero
+	Created time.Time
+}
+
+// page is the drawing commands and images of a page
+type page struct {
+	content *bytes.Buffer
+	// indexes of the images drawn on the page
+	images []int
+}
+
+// embeddedImage is a JPEG drawn on at least one page
+type embeddedImage struct {
+	data       []byte
+	width      int
+	height     int
+	colorSpace string
+	// CMYK JPEGs from Adobe software store inverted values
+	invert bool
+}
+
+// Document lays out text and images from the top of the page down, adding pages as they fill up
+type Document struct {
+	info   Info
+	pages  []*page
+	images []*embeddedImage
+	// distance from the bottom of the page to where the next line goes
+	y float64
+	// Footer is the text at the bottom of each page by its page number, nothing is drawn if it is nil or empty
+	Footer func(page int) string
+}
+
+// NewDocument creates a document with a blank first page
+func NewDocument(info Info) *Document {
+	document := &Document{info: info}
+	document.NewPage()
+	return document
+}
+
+// NewPage starts a new page, following content goes at its top
+func (d *Document) NewPage() {
+	d.pages = append(d.pages, &page{content: new(bytes.Buffer)})
+	d.y = PageHeight - Margin
+}
+
+// Page is the one based number of the page being written
+func (d *Document) Page() int {
+	return len(d.pages)
+}
+
+// Remaining is the height left on the page being written
+func (d *Document) Remaining() float64 {
+	return d.y - Margin
+}
+
+// Space moves the next content down by height, starting a new page if it does not fit
+func (d *Document) Space(height float64) {
+	if height > d.Remaining() {
+		d.NewPage()
+		return
+	}
+
+	d.y -= height
+}
+
+// Paragraph writes text wrapped to the width of the page
+func (d *Document) Paragraph(text string, font Font, size float64) {
+	d.paragraph(text, font, size, 0)
+}
+
+// Indented writes text wrapped to the width of the page, every line indented by indent points
+func (d *Document) Indented(text string, font Font, size float64, indent float64) {
+	d.paragraph(text, font, size, indent)
+}
+
+// paragraph writes text wrapped to the width of the page after the indent
+func (d *Document) paragraph(text string, font Font, size float64, indent float64) {
+	lineHeight := size * lineSpacing
+	for _, line := range WrapText(text, font, size, PageWidth-2*Margin-indent) {
+		if lineHeight > d.Remaining() {
+			d.NewPage()
+		}
+
+		d.y -= lineHeight
+		d.text(Margin+indent, d.y+(lineHeight-size)/2+size*0.2, line, font, size)
+	}
+}
+
+// Row writes left text and right aligned right text on one line, such as an entry of a table of contents
+// the gap between them is filled with dots
+func (d *Document) Row(left, right string, font Font, size float64) {
+	lineHeight := size * lineSpacing
+	if lineHeight > d.Remaining() {
+		d.NewPage()
+	}
+
+	d.y -= lineHeight
+	baseline := d.y + (lineHeight-size)/2 + size*0.2
+	width := PageWidth - 2*Margin
+	rightWidth := TextWidth(right, font, size)
+	dotWidth := TextWidth(".", font, size)
+
+	// shorten left text that would run into the right text
+	room := width - rightWidth - 4*dotWidth
+	for TextWidth(left, font, size) > room {
+		runes := []rune(strings.TrimSuffix(left, "…"))
+		if len(runes) == 0 {
+			break
+		}
+
+		left = string(runes[:len(runes)-1]) + "…"
+	}
+
+	leftWidth := TextWidth(left, font, size)
+	dots := int((width - leftWidth - rightWidth - 2*dotWidth) / dotWidth)
+	if dots < 0 {
+		dots = 0
+	}
+
+	d.text(Margin, baseline, left+" "+strings.Repeat(".", dots), font, size)
+	d.text(Margin+width-rightWidth, baseline, right, font, size)
+}
+
+// text draws a single line with its baseline at x, y
+func (d *Document) text(x, y float64, text string, font Font, size float64) {
+	fmt.Fprintf(d.pages[len(d.pages)-1].content, "BT /F%d %s Tf %s %s Td %s Tj ET\n",
+		font+1, number(size), number(x), number(y), literal(text))
+}
+
+// Image draws a JPEG centered on the page, scaled down to fit maxWidth and maxHeight
+// a new page is started when it does not fit on the current one
+func (d *Document) Image(data []byte, maxWidth, maxHeight float64) error {
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("image must be a JPEG: %s", err)
+	}
+
+	if config.Width == 0 || config.Height == 0 {
+		return fmt.Errorf("image must not be empty")
+	}
+
+	img := &embeddedImage{data: data, width: config.Width, height: config.Height}
+	switch config.ColorModel {
+	case color.GrayModel:
+		img.colorSpace = "/DeviceGray"
+	case color.CMYKModel:
+		img.colorSpace = "/DeviceCMYK"
+		img.invert = true
+	default:
+		img.colorSpace = "/DeviceRGB"
+	}
+
+	maxWidth = minimum(maxWidth, PageWidth-2*Margin)
+	maxHeight = minimum(maxHeight, PageHeight-2*Margin)
+	scale := minimum(maxWidth/float64(config.Width), maxHeight/float64(config.Height))
+	width := float64(config.Width) * scale
+	height := float64(config.Height) * scale
+
+	if height > d.Remaining() {
+		d.NewPage()
+	}
+
+	current := d.pages[len(d.pages)-1]
+	current.images = append(current.images, len(d.images))
+	d.images = append(d.images, img)
+
+	d.y -= height
+	fmt.Fprintf(current.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n",
+		number(width), number(height), number((PageWidth-width)/2), number(d.y), len(d.images))
+
+	return nil
+}
+
+// footer is the text at the bottom of the page
+func (d *Document) footer(page int) string {
+	if d.Footer == nil {
+		return ""
+	}
+
+	return d.Footer(page)
+}
+
+// minimum is the smaller of a and b
+func minimum(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// number formats a coordinate without needless digits
+func number(value float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+}
+
+// literal encodes text as a PDF string in WinAnsiEncoding
+func literal(text string) string {
+	buf := strings.Builder{}
+	buf.WriteByte('(')
+	for _, char := range encodeWinAnsi(text) {
+		switch {
+		case char == '(' || char == ')' || char == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(char)
+		case char < 32 || char > 126:
+			fmt.Fprintf(&buf, "\\%03o", char)
+		default:
+			buf.WriteByte(char)
+		}
+	}
+
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+// WriteTo writes the document as a PDF
+func (d *Document) WriteTo(writer io.Writer) (int64, error) {
+	pdf := &objectWriter{buf: new(bytes.Buffer)}
+	pdf.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// catalog, page tree, fonts and info come first, pages and images follow
+	const (
+		catalogObject = 1
+		pagesObject   = 2
+		fontObject    = 3
+		infoObject    = 3 + len(fontNames)
+	)
+
+	firstImage := infoObject + 1
+	firstPage := firstImage + len(d.images)
+
+	pdf.object(catalogObject, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObject))
+
+	kids := make([]string, 0, len(d.pages))
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+
+	pdf.object(pagesObject, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		strings.Join(kids, " "), len(d.pages), number(PageWidth), number(PageHeight)))
+
+	fonts := strings.Builder{}
+	for font, name := range fontNames {
+		pdf.object(fontObject+font, fmt.Sprintf(
+			"<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fmt.Fprintf(&fonts, "/F%d %d 0 R ", font+1, fontObject+font)
+	}
+
+	created := d.info.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+
+	info := strings.Builder{}
+	info.WriteString("<< /Producer (recipe-card)")
+	for _, entry := range []struct{ key, value string }{
+		{"Title", d.info.Title},
+		{"Author", d.info.Author},
+		{"Subject", d.info.Subject},
+		{"Keywords", d.info.Keywords},
+	} {
+		if entry.value != "" {
+			fmt.Fprintf(&info, " /%s %s", entry.key, literal(entry.value))
+		}
+	}
+
+	fmt.Fprintf(&info, " /CreationDate (D:%s) >>", created.UTC().Format("20060102150405Z"))
+	pdf.object(infoObject, info.String())
+
+	for i, img := range d.images {
+		decode := ""
+		if img.invert {
+			decode = " /Decode [1 0 1 0 1 0 1 0]"
+		}
+
+		pdf.stream(firstImage+i, fmt.Sprintf(
+			"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode%s",
+			img.width, img.height, img.colorSpace, decode), img.data)
+	}
+
+	for i, p := range d.pages {
+		content := p.content.Bytes()
+		if footer := d.footer(i + 1); footer != "" {
+			content = append(append([]byte{}, content...), fmt.Sprintf("BT /F1 9 Tf %s %s Td %s Tj ET\n",
+				number((PageWidth-TextWidth(footer, Regular, 9))/2), number(Margin/2), literal(footer))...)
+		}
+
+		images := strings.Builder{}
+		for _, index := range p.images {
+			fmt.Fprintf(&images, "/Im%d %d 0 R ", index+1, firstImage+index)
+		}
+
+		pdf.object(firstPage+2*i, fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /Resources << /Font << %s>> /XObject << %s>> >> /Contents %d 0 R >>",
+			pagesObject, fonts.String(), images.String(), firstPage+2*i+1))
+
+		compressed := new(bytes.Buffer)
+		zlibWriter := zlib.NewWriter(compressed)
+		zlibWriter.Write(content)
+		zlibWriter.Close()
+		pdf.stream(firstPage+2*i+1, "/Filter /FlateDecode", compressed.Bytes())
+	}
+
+	pdf.finish(catalogObject, infoObject)
+
+	return pdf.buf.WriteTo(writer)
+}
+
+// objectWriter numbers the objects of a PDF and keeps their offsets for the cross reference table
+type objectWriter struct {
+	buf     *bytes.Buffer
+	offsets map[int]int
+}
+
+// object writes an object, objects may be written in any order
+func (w *objectWriter) object(number int, body string) {
+	w.start(number)
+	w.buf.WriteString(body)
+	w.buf.WriteString("\nendobj\n")
+}
+
+// stream writes a stream object with the extra dictionary entries
+func (w *objectWriter) stream(number int, dictionary string, data []byte) {
+	w.start(number)
+	fmt.Fprintf(w.buf, "<< %s /Length %d >>\nstream\n", dictionary, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// start records where an object begins
+func (w *objectWriter) start(number int) {
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+
+	w.offsets[number] = w.buf.Len()
+	fmt.Fprintf(w.buf, "%d 0 obj\n", number)
+}
+
+// finish writes the cross reference table and trailer
+func (w *objectWriter) finish(root, info int) {
+	xref := w.buf.Len()
+	size := len(w.offsets) + 1
+	fmt.Fprintf(w.buf, "xref\n0 %d\n0000000000 65535 f\r\n", size)
+	for number := 1; number < size; number++ {
+		fmt.Fprintf(w.buf, "%010d 00000 n\r\n", w.offsets[number])
+	}
+
+	fmt.Fprintf(w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, root, info, xref)
+}
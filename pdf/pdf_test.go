@@ -0,0 +1,169 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamRegexp finds the compressed content streams of a written document
+var streamRegexp = regexp.MustCompile(`(?s)<< /Filter /FlateDecode /Length (\d+) >>\nstream\n`)
+
+// contents decompresses the content stream of every page
+func contents(t *testing.T, data []byte) []string {
+	pages := []string{}
+	for _, match := range streamRegexp.FindAllSubmatchIndex(data, -1) {
+		length, _ := strconv.Atoi(string(data[match[2]:match[3]]))
+		reader, err := zlib.NewReader(bytes.NewReader(data[match[1] : match[1]+length]))
+		if err != nil {
+			t.Fatal("Failed to decompress content stream", err)
+		}
+
+		content, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatal("Failed to decompress content stream", err)
+		}
+
+		pages = append(pages, string(content))
+	}
+
+	return pages
+}
+
+func TestDocument(t *testing.T) {
+	document := NewDocument(Info{Title: "Banana (Bread)", Author: "Grandma", Created: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)})
+	document.Footer = func(page int) string {
+		return "Page " + strconv.Itoa(page)
+	}
+
+	document.Paragraph("Banana Bread", Bold, 20)
+	buf := new(bytes.Buffer)
+	err := jpeg.Encode(buf, image.NewGray(image.Rect(0, 0, 200, 100)), nil)
+	if err != nil {
+		t.Fatal("Failed to encode image", err)
+	}
+
+	err = document.Image(buf.Bytes(), 300, 300)
+	if err != nil {
+		t.Fatal("Failed to add image", err)
+	}
+
+	if err = document.Image([]byte("not a jpeg"), 100, 100); err == nil {
+		t.Error("Expected an error adding an image that is not a JPEG")
+	}
+
+	// enough lines to fill the first page
+	for i := 0; i < 60; i++ {
+		document.Paragraph("Mix ½ cup of sugar at 350°F", Regular, 11)
+	}
+
+	if document.Page() != 2 {
+		t.Errorf("Expected the text to go on to a second page, on page %d", document.Page())
+	}
+
+	document.NewPage()
+	document.Row("Banana Bread", "12", Regular, 11)
+
+	output := new(bytes.Buffer)
+	_, err = document.WriteTo(output)
+	if err != nil {
+		t.Fatal("Failed to write document", err)
+	}
+
+	data := output.Bytes()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("Expected a PDF header and end of file marker")
+	}
+
+	// every entry of the cross reference table must point at its object
+	xref := bytes.LastIndex(data, []byte("\nxref\n")) + 1
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if startxref == nil || string(startxref[1]) != strconv.Itoa(xref) {
+		t.Fatalf("startxref does not point at the cross reference table at %d", xref)
+	}
+
+	lines := strings.Split(string(data[xref:]), "\n")
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for number := 1; number < count; number++ {
+		offset, _ := strconv.Atoi(strings.Fields(lines[2+number])[0])
+		if !bytes.HasPrefix(data[offset:], []byte(strconv.Itoa(number)+" 0 obj\n")) {
+			t.Errorf("Cross reference of object %d does not point at it", number)
+		}
+	}
+
+	if !bytes.Contains(data, []byte("/Count 3 ")) {
+		t.Error("Expected 3 pages in the page tree")
+	}
+
+	if !bytes.Contains(data, []byte(`/Title (Banana \(Bread\))`)) || !bytes.Contains(data, []byte("/CreationDate (D:20200102030405Z)")) {
+		t.Error("Expected the document info to be written")
+	}
+
+	if !bytes.Contains(data, []byte("/Width 200 /Height 100 /ColorSpace /DeviceGray")) {
+		t.Error("Expected the image to be embedded")
+	}
+
+	pages := contents(t, data)
+	if len(pages) != 3 {
+		t.Fatalf("Expected 3 content streams, got %d", len(pages))
+	}
+
+	for _, text := range []string{"(Banana Bread) Tj", "/Im1 Do", `(Mix \275 cup of sugar at 350\260F) Tj`, "(Page 1) Tj"} {
+		if !strings.Contains(pages[0], text) {
+			t.Errorf("Expected the first page to contain %s", text)
+		}
+	}
+
+	if !strings.Contains(pages[2], "(12) Tj") || !strings.Contains(pages[2], "(Banana Bread ....") {
+		t.Error("Expected a row with dots leading to the page number")
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	lines := WrapText("the quick brown fox jumps over the lazy dog", Regular, 10, 100)
+	if len(lines) < 2 {
+		t.Fatalf("Expected the text to wrap, got %v", lines)
+	}
+
+	for _, line := range lines {
+		if TextWidth(line, Regular, 10) > 100 {
+			t.Errorf("Line \"%s\" is wider than 100 points", line)
+		}
+	}
+
+	if strings.Join(lines, " ") != "the quick brown fox jumps over the lazy dog" {
+		t.Errorf("Wrapping lost words: %v", lines)
+	}
+
+	long := WrapText(strings.Repeat("m", 40), Regular, 10, 100)
+	if len(long) < 2 || strings.Join(long, "") != strings.Repeat("m", 40) {
+		t.Errorf("Expected a long word to be broken, got %v", long)
+	}
+
+	if empty := WrapText("", Regular, 10, 100); len(empty) != 1 || empty[0] != "" {
+		t.Errorf("Expected one empty line, got %v", empty)
+	}
+}
+
+func TestEncodeWinAnsi(t *testing.T) {
+	tests := map[string]string{
+		"crème brûlée": "cr\xe8me br\xfbl\xe9e",
+		"“quoted” – …": "\x93quoted\x94 \x96 \x85",
+		"⅓ cup":        "1/3 cup",
+		"鍋":            "?",
+		"a\tb":         "a b",
+	}
+
+	for text, expected := range tests {
+		if encoded := string(encodeWinAnsi(text)); encoded != expected {
+			t.Errorf("encodeWinAnsi(\"%s\") != %q: %q", text, expected, encoded)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+const (
+	// recipePrintSuffix is added to a recipe page URL for its printable card
+	recipePrintSuffix = "/print"
+
+	// printMaxServings keeps scaled recipes to something a kitchen could make
+	printMaxServings = 1000
+)
+
+// printSizes are the index cards a recipe can be printed on, in inches as they are held
+var printSizes = []struct {
+	name   string
+	width  int
+	height int
+}{
+	{"4x6", 6, 4},
+	{"5x8", 8, 5},
+}
+
+// recipePrintURL is the relative URL of the printable card of the recipe title
+func recipePrintURL(title string) string {
+	return recipePattern + url.PathEscape(title) + recipePrintSuffix
+}
+
+// PrintRecipe handles the printable index card of the recipe titled id
+// size picks the card, image includes the stock image and servings scales the ingredients
+func (h *Handler) PrintRecipe(w http.ResponseWriter, r *http.Request, id string) {
+	rec, exists := h.getRecipe(id)
+	if !exists {
+		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+		return
+	}
+
+	query := r.URL.Query()
+	size := query.Get("size")
+	if size == "" {
+		size = printSizes[0].name
+	}
+
+	tmplPrint := &TemplatePrint{
+		URL:       recipePrintURL(rec.Title),
+		RecipeURL: recipePattern + url.PathEscape(rec.Title),
+		Title:     rec.Title,
+		Image:     query.Get("image") != "",
+		Servings:  strings.TrimSpace(query.Get("servings")),
+	}
+
+	for _, printSize := range printSizes {
+		selected := printSize.name == size
+		if selected {
+			tmplPrint.Width = printSize.width
+			tmplPrint.Height = printSize.height
+		}
+
+		tmplPrint.Sizes = append(tmplPrint.Sizes, &TemplateOption{
+			Value:    printSize.name,
+			Label:    strings.Replace(printSize.name, "x", " × ", 1) + " in",
+			Selected: selected,
+		})
+	}
+
+	if tmplPrint.Width == 0 {
+		http.Error(w, "size must be one of 4x6 or 5x8", http.StatusBadRequest)
+		return
+	}
+
+	if tmplPrint.Image && len(rec.Image) > 0 {
+		tmplPrint.StockImage = stockImagePatten + url.PathEscape(rec.Title+".jpg")
+	}
+
+	if tmplPrint.Servings != "" {
+		servings, err := strconv.ParseFloat(tmplPrint.Servings, 64)
+		if err != nil || servings <= 0 || servings > printMaxServings {
+			tmplPrint.ScaleError = "Servings must be a number from 1 to 1000"
+		} else if scaled, err := rec.Scale(servings); err != nil {
+			tmplPrint.ScaleError = "Unable to scale: " + err.Error()
+		} else {
+			rec = scaled
+		}
+	}
+
+	for _, category := range recipe.ValidCategoriesOrder {
+		if lines, exists := rec.Info[category]; exists {
+			tmplPrint.Sections = append(tmplPrint.Sections, &TemplatePrintSection{
+				Name:  category,
+				Lines: lines,
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	h.templates.ExecuteTemplate(w, "print", &TemplateData{
+		PageTitle: "Recipe Card - " + rec.Title,
+		Print:     tmplPrint,
+	})
+}
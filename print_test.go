@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrintRecipe(t *testing.T) {
+	handler := newTestHandler(t)
+	templates, err := NewTemplate(nil)
+	if err != nil {
+		t.Fatal("Failed to parse templates", err)
+	}
+
+	handler.templates = templates
+	pancakes, _ := handler.getRecipe("Pancakes")
+	pancakes.Info["serves"] = []string{"4"}
+
+	tests := []struct {
+		url      string
+		status   int
+		contains []string
+		missing  []string
+	}{
+		{
+			"/recipe/Pancakes/print",
+			http.StatusOK,
+			[]string{"size: 6in 4in", "<p>1 cup flour</p>", `<option value="4x6" selected>`},
+			[]string{"stock-images"},
+		},
+		{
+			"/recipe/Pancakes/print?size=5x8&servings=8",
+			http.StatusOK,
+			[]string{"size: 8in 5in", "<p>2 cup flour</p>", "<p>8</p>", `value="8"`},
+			nil,
+		},
+		{
+			"/recipe/Pancakes/print?servings=lots",
+			http.StatusOK,
+			[]string{"Servings must be a number", "<p>1 cup flour</p>"},
+			nil,
+		},
+		{
+			"/recipe/Banana%20Bread/print?servings=2",
+			http.StatusOK,
+			[]string{"Unable to scale"},
+			nil,
+		},
+		{"/recipe/Pancakes/print?size=3x5", http.StatusBadRequest, nil, nil},
+		{"/recipe/Waffles/print", http.StatusTemporaryRedirect, nil, nil},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		handler.Recipe(recorder, httptest.NewRequest(http.MethodGet, test.url, nil))
+		if recorder.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.url, test.status, recorder.Code)
+			continue
+		}
+
+		body := recorder.Body.String()
+		for _, text := range test.contains {
+			if !strings.Contains(body, text) {
+				t.Errorf("%s: expected the card to contain %s", test.url, text)
+			}
+		}
+
+		for _, text := range test.missing {
+			if strings.Contains(body, text) {
+				t.Errorf("%s: expected the card not to contain %s", test.url, text)
+			}
+		}
+	}
+
+	if pancakes.Info["ingredients"][0] != "1 cup flour" {
+		t.Error("Printing a scaled card changed the recipe")
+	}
+}
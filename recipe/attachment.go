@@ -0,0 +1,146 @@
+package recipe
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// pdfChunkSize is how much of a PDF is searched for pages at once
+	pdfChunkSize = 64 << 10
+
+	// pdfMatchOverlap is searched again with the next chunk, so matches
+	// crossing chunks are found, it is longer than any match
+	pdfMatchOverlap = 256
+)
+
+var (
+	// pdfPageRegexp finds page objects, the \b leaves out the /Pages tree nodes
+	pdfPageRegexp = regexp.MustCompile(`/Type\s*/Page\b`)
+
+	// pdfCountRegexp finds the number of pages below a /Pages tree node
+	pdfCountRegexp = regexp.MustCompile(`/Count\s+(\d+)`)
+)
+
+// pageCounts remembers the page counts of PDFs by path, so they are only
+// read again when they change rather than every time a recipe is parsed
+var pageCounts = struct {
+	sync.Mutex
+	counts map[string]pageCount
+}{counts: make(map[string]pageCount)}
+
+// pageCount is the page count of a PDF as it was when it was counted
+type pageCount struct {
+	modTime time.Time
+	size    int64
+	pages   int
+}
+
+// Attachment is a document in a recipe's folder, such as a scanned PDF of the card
+type Attachment struct {
+	Path string `json:"path"`
+	// number of pages, 0 if they could not be counted
+	Pages int `json:"pages"`
+}
+
+// IsAttachment checks if the file name is a document that is collected as an attachment
+func IsAttachment(name string) bool {
+	return strings.ToLower(filepath.Ext(name)) == ".pdf"
+}
+
+// newAttachment creates an attachment for the PDF at path
+// a PDF that can not be read is still attached, without a page count
+func newAttachment(path string, info os.FileInfo) *Attachment {
+	attachment := &Attachment{Path: path}
+
+	pageCounts.Lock()
+	cached, exists := pageCounts.counts[path]
+	pageCounts.Unlock()
+
+	if exists && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		attachment.Pages = cached.pages
+		return attachment
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return attachment
+	}
+
+	defer file.Close()
+
+	attachment.Pages, _ = PDFPageCount(file)
+
+	pageCounts.Lock()
+	pageCounts.counts[path] = pageCount{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		pages:   attachment.Pages,
+	}
+	pageCounts.Unlock()
+
+	return attachment
+}
+
+// PDFPageCount counts the pages of a PDF, reading it a chunk at a time
+// page objects inside compressed object streams can not be seen, so the
+// largest page tree count is used when there are none
+func PDFPageCount(reader io.Reader) (int, error) {
+	buf := make([]byte, 0, pdfMatchOverlap+pdfChunkSize)
+	pages, largestCount := 0, 0
+	for first := true; ; first = false {
+		n, err := io.ReadFull(reader, buf[len(buf):len(buf)+pdfChunkSize])
+		buf = buf[:len(buf)+n]
+		last := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !last {
+			return 0, err
+		}
+
+		if first && !bytes.HasPrefix(buf, []byte("%PDF-")) {
+			return 0, fmt.Errorf("not a PDF")
+		}
+
+		// matches starting in the overlap are counted with the next chunk
+		end := len(buf)
+		if !last {
+			end -= pdfMatchOverlap
+		}
+
+		for _, match := range pdfPageRegexp.FindAllIndex(buf, -1) {
+			if match[0] < end {
+				pages++
+			}
+		}
+
+		for _, match := range pdfCountRegexp.FindAllSubmatchIndex(buf, -1) {
+			if match[0] >= end {
+				continue
+			}
+
+			count, err := strconv.Atoi(string(buf[match[2]:match[3]]))
+			if err == nil && count > largestCount {
+				largestCount = count
+			}
+		}
+
+		if last {
+			break
+		}
+
+		buf = buf[:copy(buf, buf[end:])]
+	}
+
+	if pages > 0 {
+		return pages, nil
+	}
+
+	return largestCount, nil
+}
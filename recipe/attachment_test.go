@@ -0,0 +1,168 @@
+package recipe
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPDFPageCount(t *testing.T) {
+	tests := []struct {
+		pdf      string
+		expected int
+	}{
+		{
+			"%PDF-1.4\n1 0 obj << /Type /Pages /Kids [2 0 R 3 0 R] /Count 2 >> endobj\n" +
+				"2 0 obj << /Type /Page /Parent 1 0 R >> endobj\n3 0 obj <</Type/Page/Parent 1 0 R>> endobj\n",
+			2,
+		},
+		// page objects hidden in a compressed object stream
+		{"%PDF-1.5\n1 0 obj << /Type /Pages /Count 7 >> endobj\n4 0 obj << /Type /Pages /Count 3 >> endobj\n", 7},
+		{"%PDF-1.4\n", 0},
+	}
+
+	for _, test := range tests {
+		pages, err := PDFPageCount(strings.NewReader(test.pdf))
+		if err != nil {
+			t.Errorf("Failed to count pages of \"%s\": %s", test.pdf, err)
+		}
+
+		if pages != test.expected {
+			t.Errorf("Expected %d pages, got %d for \"%s\"", test.expected, pages, test.pdf)
+		}
+	}
+
+	if _, err := PDFPageCount(strings.NewReader("not a pdf")); err == nil {
+		t.Error("Expected an error for data that is not a PDF")
+	}
+}
+
+func TestPDFPageCountChunks(t *testing.T) {
+	// enough pages for several chunks, so some cross from one chunk to the next
+	pdf := "%PDF-1.4\n"
+	for i := 0; len(pdf) < 3*pdfChunkSize; i++ {
+		pdf += fmt.Sprintf("%d 0 obj << /Type /Page /Contents %d 0 R >> endobj\n", i, i+100000)
+	}
+
+	pages := strings.Count(pdf, "/Type /Page ")
+
+	// a page tree node right where a chunk ends is not a page
+	pdf += strings.Repeat(" ", 4*pdfChunkSize-len(pdf)-len("/Type /Page"))
+	pdf += "/Type /Pages /Count 12345 >>\n"
+
+	counted, err := PDFPageCount(strings.NewReader(pdf))
+	if err != nil {
+		t.Fatal("Failed to count pages", err)
+	}
+
+	if counted != pages {
+		t.Errorf("Expected %d pages, got %d", pages, counted)
+	}
+
+	// so is a page tree count split between chunks
+	pdf = "%PDF-1.5\n" + strings.Repeat(" ", pdfChunkSize-len("%PDF-1.5\n/Count 1")) + "/Count 12345 >>\n"
+	counted, err = PDFPageCount(strings.NewReader(pdf))
+	if err != nil {
+		t.Fatal("Failed to count pages", err)
+	}
+
+	if counted != 12345 {
+		t.Errorf("Expected 12345 pages from the page tree, got %d", counted)
+	}
+}
+
+func TestParseFilesAttachments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-attachments")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	docxPath := writeTestDocx(t, dir, []string{"Family Recipe", "Toast", "Ingredients", "Bread"})
+	files := map[string]string{
+		"card.pdf":  "%PDF-1.4\n<< /Type /Page >>\n",
+		"BACK.PDF":  "garbage",
+		"front.jpg": "",
+		"notes.txt": "",
+	}
+
+	for name, data := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal("Failed to write", name, err)
+		}
+	}
+
+	rec := &Recipe{DocxPath: docxPath}
+	err = rec.ParseFiles()
+	if err != nil {
+		t.Fatal("Failed to parse files", err)
+	}
+
+	expected := []Attachment{
+		{Path: filepath.Join(dir, "BACK.PDF"), Pages: 0},
+		{Path: filepath.Join(dir, "card.pdf"), Pages: 1},
+	}
+
+	if len(rec.Attachments) != len(expected) {
+		t.Fatalf("Expected %d attachments, got %d", len(expected), len(rec.Attachments))
+	}
+
+	for i, attachment := range rec.Attachments {
+		if *attachment != expected[i] {
+			t.Errorf("Expected attachment %v, got %v", expected[i], *attachment)
+		}
+	}
+
+	if len(rec.ScanPaths) != 1 || rec.ScanPaths[0] != filepath.Join(dir, "front.jpg") {
+		t.Errorf("Expected only front.jpg as a scan, got %v", rec.ScanPaths)
+	}
+}
+
+func TestAttachmentPageCountCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-attachments")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "card.pdf")
+	modified := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	attach := func(pdf string) int {
+		err := ioutil.WriteFile(path, []byte(pdf), 0644)
+		if err == nil {
+			err = os.Chtimes(path, modified, modified)
+		}
+
+		if err != nil {
+			t.Fatal("Failed to write PDF", err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal("Failed to stat PDF", err)
+		}
+
+		return newAttachment(path, info).Pages
+	}
+
+	if pages := attach("%PDF-1.4\n<< /Type /Page >>\n"); pages != 1 {
+		t.Fatalf("Expected 1 page, got %d", pages)
+	}
+
+	// the same size and time is not read again
+	if pages := attach("%PDF-1.4\n<< /Type /Pag_ >>\n"); pages != 1 {
+		t.Errorf("Expected the cached count of 1 page, got %d", pages)
+	}
+
+	modified = modified.Add(time.Minute)
+	if pages := attach("%PDF-1.4\n<< /Type /Pag_ >>\n"); pages != 0 {
+		t.Errorf("Expected the changed PDF to be counted again, got %d pages", pages)
+	}
+}
@@ -0,0 +1,206 @@
+package recipe
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tblyler/recipe-card/doc"
+)
+
+// ErrRecipeExists is returned when creating a recipe whose folder is already there
+var ErrRecipeExists = errors.New("a recipe folder with that name already exists")
+
+// docxHeading is the line before the title in the recipe template
+const docxHeading = "Family Recipe"
+
+// fileNameReplacer removes characters that are not allowed in file names on common file systems
+var fileNameReplacer = strings.NewReplacer(
+	"/", "-",
+	"\\", "-",
+	":", "-",
+	"*", "",
+	"?", "",
+	"\"", "",
+	"<", "",
+	">", "",
+	"|", "-",
+)
+
+// FileName converts a title to a name safe for files and folders
+func FileName(title string) string {
+	name := strings.Trim(fileNameReplacer.Replace(title), ". ")
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// cleanCategory makes sure a slash separated category stays inside of the recipes path
+func cleanCategory(category string) (string, error) {
+	parts := []string{}
+	for _, part := range strings.Split(filepath.ToSlash(category), "/") {
+		part = strings.TrimSpace(part)
+		if part == "" || part == "." {
+			continue
+		}
+
+		if part == ".." {
+			return "", fmt.Errorf("category must not contain \"..\"")
+		}
+
+		parts = append(parts, FileName(part))
+	}
+
+	return strings.Join(parts, "/"), nil
+}
+
+// placeholderImage is the stock image for recipes created without one
+func placeholderImage() ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, 600, 400))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{0xf8, 0xf3, 0xe6, 0xff}}, image.ZP, draw.Src)
+
+	buf := new(bytes.Buffer)
+	err := jpeg.Encode(buf, img, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// docxParagraphs lays out the recipe the way ParseFiles reads it, a line
+// mentioning "recipe", the title, then each category heading and its lines
+func (r *Recipe) docxParagraphs() []doc.Paragraph {
+	paragraphs := []doc.Paragraph{
+		{Text: docxHeading, Heading: true},
+		{Text: r.Title, Heading: true},
+	}
+
+	for _, category := range ValidCategoriesOrder {
+		lines := []string{}
+		for _, line := range r.Info[category] {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+
+		if len(lines) == 0 {
+			continue
+		}
+
+		paragraphs = append(paragraphs, doc.Paragraph{
+			Text:    strings.Title(category) + ":",
+			Heading: true,
+		})
+
+		for _, line := range lines {
+			paragraphs = append(paragraphs, doc.Paragraph{Text: line})
+		}
+	}
+
+	return paragraphs
+}
+
+// Create writes a new recipe into its own folder under the category in recipesPath
+// the title, category, info, tags, author and language of rec are used and the image
+// must be a JPEG, a placeholder is used if it is empty
+// the created recipe is parsed back from its files
+func Create(recipesPath string, rec *Recipe, image []byte) (*Recipe, error) {
+	title := strings.TrimSpace(rec.Title)
+	name := FileName(title)
+	if name == "" {
+		return nil, errors.New("the title must not be empty")
+	}
+
+	category, err := cleanCategory(rec.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(image) == 0 {
+		image, err = placeholderImage()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	categoryPath := filepath.Join(recipesPath, filepath.FromSlash(category))
+	err = os.MkdirAll(categoryPath, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(categoryPath, name)
+	err = os.Mkdir(dir, 0755)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, ErrRecipeExists
+		}
+
+		return nil, err
+	}
+
+	// leave nothing behind if the recipe can not be written
+	created := false
+	defer func() {
+		if !created {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	docxPath := filepath.Join(dir, name+".docx")
+	file, err := os.OpenFile(docxPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	newRecipe := &Recipe{
+		Title: title,
+		Info:  rec.Info,
+	}
+
+	err = doc.WriteDocx(file, newRecipe.docxParagraphs(), image, doc.Properties{
+		Title:    title,
+		Creator:  strings.TrimSpace(rec.Author),
+		Keywords: strings.Join(rec.Tags, ", "),
+		Language: NormalizeLanguage(rec.Language),
+		Created:  now,
+		Modified: now,
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// a chosen language wins over detecting it
+	if language := NormalizeLanguage(rec.Language); language != "" {
+		err = WriteSidecar(dir, &Sidecar{Language: language})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	newRecipe = &Recipe{
+		DocxPath: docxPath,
+		Category: category,
+	}
+
+	err = newRecipe.ParseFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	created = true
+
+	return newRecipe, nil
+}
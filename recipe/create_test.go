@@ -0,0 +1,115 @@
+package recipe
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileName(t *testing.T) {
+	tests := map[string]string{
+		"Pancakes":                "Pancakes",
+		" Mom's Salt/Pepper Rub ": "Mom's Salt-Pepper Rub",
+		"What? Cake!":             "What Cake!",
+		"...":                     "",
+	}
+
+	for title, expected := range tests {
+		if name := FileName(title); name != expected {
+			t.Errorf("FileName(\"%s\") != \"%s\": \"%s\"", title, expected, name)
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-create")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	rec, err := Create(dir, &Recipe{
+		Title:    "Grandma's Pancakes",
+		Category: "Breakfast/ Sweet ",
+		Author:   "Grandma",
+		Tags:     []string{"breakfast", "quick"},
+		Language: "en-US",
+		Info: map[string][]string{
+			"serves":      {"4"},
+			"ingredients": {"1 cup flour", " ", "1 egg"},
+			"preparation": {"Mix", "Fry"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal("Failed to create recipe", err)
+	}
+
+	expectedPath := filepath.Join(dir, "Breakfast", "Sweet", "Grandma's Pancakes", "Grandma's Pancakes.docx")
+	if rec.DocxPath != expectedPath {
+		t.Errorf("DocxPath != \"%s\": \"%s\"", expectedPath, rec.DocxPath)
+	}
+
+	if rec.Title != "Grandma's Pancakes" {
+		t.Errorf("Title != \"Grandma's Pancakes\": \"%s\"", rec.Title)
+	}
+
+	if rec.Category != "Breakfast/Sweet" {
+		t.Errorf("Category != \"Breakfast/Sweet\": \"%s\"", rec.Category)
+	}
+
+	if rec.Author != "Grandma" {
+		t.Errorf("Author != \"Grandma\": \"%s\"", rec.Author)
+	}
+
+	if strings.Join(rec.Tags, "|") != "breakfast|quick" {
+		t.Errorf("Unexpected tags %v", rec.Tags)
+	}
+
+	if rec.Language != "en" {
+		t.Errorf("Language != \"en\": \"%s\"", rec.Language)
+	}
+
+	if strings.Join(rec.Info["ingredients"], "|") != "1 cup flour|1 egg" {
+		t.Errorf("Unexpected ingredients %v", rec.Info["ingredients"])
+	}
+
+	if strings.Join(rec.Info["preparation"], "|") != "Mix|Fry" {
+		t.Errorf("Unexpected preparation %v", rec.Info["preparation"])
+	}
+
+	if len(rec.Image) == 0 {
+		t.Error("Expected a placeholder image")
+	}
+
+	// recipes found by walking the path are the same
+	recipes, err := RecipesFromPath(dir)
+	if err != nil {
+		t.Fatal("Failed to get recipes", err)
+	}
+
+	if len(recipes) != 1 || recipes[0].Title != rec.Title || recipes[0].Category != rec.Category {
+		t.Errorf("Unexpected recipes from path %v", recipes)
+	}
+
+	_, err = Create(dir, &Recipe{Title: "Grandma's Pancakes", Category: "Breakfast/Sweet"}, nil)
+	if err != ErrRecipeExists {
+		t.Error("Expected ErrRecipeExists, got", err)
+	}
+
+	_, err = Create(dir, &Recipe{Title: "Escape", Category: "../outside"}, nil)
+	if err == nil {
+		t.Error("Expected an error for a category outside of the recipes path")
+	}
+
+	_, err = Create(dir, &Recipe{Title: "Bad Image"}, []byte("not a jpeg"))
+	if err == nil {
+		t.Error("Expected an error for an image that is not a JPEG")
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, "Bad Image")); !os.IsNotExist(err) {
+		t.Error("Expected the folder of a failed recipe to be removed, got", err)
+	}
+}
@@ -0,0 +1,446 @@
+package recipe
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrNoRecipeData is returned when a web page has no schema.org Recipe
+var ErrNoRecipeData = errors.New("the page has no schema.org Recipe JSON-LD or microdata")
+
+var (
+	// tagRegexp finds HTML tags inside of text, which some sites put in their JSON-LD
+	tagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+	// breakRegexp finds the HTML that ends a line of text
+	breakRegexp = regexp.MustCompile(`(?i)<br\s*/?>|</(p|li|div|h\d)>`)
+)
+
+// microdataBlocks are the elements that start a new line in the text of microdata
+var microdataBlocks = map[atom.Atom]bool{
+	atom.Br:  true,
+	atom.Div: true,
+	atom.Li:  true,
+	atom.P:   true,
+	atom.H1:  true,
+	atom.H2:  true,
+	atom.H3:  true,
+	atom.H4:  true,
+	atom.H5:  true,
+	atom.H6:  true,
+}
+
+// ParseHTML reads the schema.org Recipe of a web page, from its JSON-LD or else its microdata
+// the title, author, tags, language and info of the recipe are set, and the URLs of its
+// images are returned as they are written in the page, the main image first
+func ParseHTML(reader io.Reader) (*Recipe, []string, error) {
+	root, err := html.Parse(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := findJSONLDRecipe(root)
+	if data == nil {
+		data = findMicrodataRecipe(root)
+	}
+
+	if data == nil {
+		return nil, nil, ErrNoRecipeData
+	}
+
+	rec, images := recipeFromSchema(data)
+	if rec.Title == "" {
+		return nil, nil, errors.New("the recipe in the page has no name")
+	}
+
+	return rec, images, nil
+}
+
+// findJSONLDRecipe finds the first Recipe in the JSON-LD scripts of a page
+// scripts that are not valid JSON are skipped, pages often have a broken one
+func findJSONLDRecipe(node *html.Node) map[string]interface{} {
+	if node.Type == html.ElementNode && node.DataAtom == atom.Script {
+		contentType := strings.ToLower(strings.TrimSpace(attribute(node, "type")))
+		if strings.HasPrefix(contentType, "application/ld+json") {
+			var data interface{}
+			if json.Unmarshal([]byte(textContent(node, false)), &data) == nil {
+				return findSchemaRecipe(data)
+			}
+		}
+
+		return nil
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if data := findJSONLDRecipe(child); data != nil {
+			return data
+		}
+	}
+
+	return nil
+}
+
+// findSchemaRecipe finds a Recipe in JSON-LD, which can be a list, a @graph or the main entity of a page
+func findSchemaRecipe(data interface{}) map[string]interface{} {
+	switch value := data.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if found := findSchemaRecipe(item); found != nil {
+				return found
+			}
+		}
+	case map[string]interface{}:
+		if isSchemaRecipe(value["@type"]) {
+			return value
+		}
+
+		for _, key := range []string{"@graph", "mainEntity"} {
+			if found := findSchemaRecipe(value[key]); found != nil {
+				return found
+			}
+		}
+	}
+
+	return nil
+}
+
+// isSchemaRecipe checks if a @type or itemtype is a Recipe, with or without the schema.org URL
+func isSchemaRecipe(schemaType interface{}) bool {
+	for _, value := range schemaValues(schemaType) {
+		text, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		for _, field := range strings.Fields(text) {
+			if field == "Recipe" || strings.HasSuffix(field, "schema.org/Recipe") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// findMicrodataRecipe finds the first Recipe item in the microdata of a page
+// its properties are put in the same shape as JSON-LD
+func findMicrodataRecipe(node *html.Node) map[string]interface{} {
+	if node.Type == html.ElementNode && hasAttribute(node, "itemscope") && isSchemaRecipe(attribute(node, "itemtype")) {
+		return microdataItem(node)
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if data := findMicrodataRecipe(child); data != nil {
+			return data
+		}
+	}
+
+	return nil
+}
+
+// microdataItem collects the properties of the item scope, items inside of it keep their own properties
+func microdataItem(scope *html.Node) map[string]interface{} {
+	item := map[string]interface{}{"@type": attribute(scope, "itemtype")}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+
+			nested := hasAttribute(child, "itemscope")
+			if names := strings.Fields(attribute(child, "itemprop")); len(names) > 0 {
+				var value interface{}
+				if nested {
+					value = microdataItem(child)
+				} else {
+					value = microdataValue(child)
+				}
+
+				for _, name := range names {
+					values, _ := item[name].([]interface{})
+					item[name] = append(values, value)
+				}
+			}
+
+			if !nested {
+				walk(child)
+			}
+		}
+	}
+
+	walk(scope)
+
+	return item
+}
+
+// microdataValue is the value of a property element, which depends on the kind of element
+func microdataValue(node *html.Node) string {
+	switch node.DataAtom {
+	case atom.Meta:
+		return attribute(node, "content")
+	case atom.Img, atom.Audio, atom.Video, atom.Source, atom.Embed, atom.Iframe:
+		return attribute(node, "src")
+	case atom.A, atom.Area, atom.Link:
+		return attribute(node, "href")
+	case atom.Object:
+		return attribute(node, "data")
+	case atom.Data, atom.Meter:
+		return attribute(node, "value")
+	case atom.Time:
+		if hasAttribute(node, "datetime") {
+			return attribute(node, "datetime")
+		}
+	}
+
+	return textContent(node, true)
+}
+
+// textContent is the text inside of a node, with a new line for each block when blocks is true
+func textContent(node *html.Node, blocks bool) string {
+	text := strings.Builder{}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			text.WriteString(node.Data)
+			return
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+
+		if blocks && microdataBlocks[node.DataAtom] {
+			text.WriteString("\n")
+		}
+	}
+
+	walk(node)
+
+	return text.String()
+}
+
+// attribute is the value of an attribute of the element, empty if it is not set
+func attribute(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+
+	return ""
+}
+
+// hasAttribute checks if the element has the attribute, even if it has no value
+func hasAttribute(node *html.Node, key string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recipeFromSchema converts a schema.org Recipe to a recipe and the URLs of its images
+func recipeFromSchema(data map[string]interface{}) (*Recipe, []string) {
+	rec := &Recipe{
+		Title:    schemaText(firstValue(data["name"])),
+		Language: NormalizeLanguage(schemaText(firstValue(data["inLanguage"]))),
+		Info:     make(map[string][]string),
+	}
+
+	authors := []string{}
+	for _, author := range schemaValues(data["author"]) {
+		if name := schemaText(author); name != "" {
+			authors = append(authors, name)
+		}
+	}
+
+	rec.Author = strings.Join(authors, ", ")
+
+	seen := make(map[string]bool)
+	for _, key := range []string{"keywords", "recipeCategory", "recipeCuisine"} {
+		for _, value := range schemaValues(data[key]) {
+			for _, tag := range strings.Split(schemaText(value), ",") {
+				tag = strings.TrimSpace(tag)
+				if tag != "" && !seen[strings.ToLower(tag)] {
+					seen[strings.ToLower(tag)] = true
+					rec.Tags = append(rec.Tags, tag)
+				}
+			}
+		}
+	}
+
+	if serves := schemaYield(data["recipeYield"]); serves != "" {
+		rec.Info["serves"] = []string{serves}
+	}
+
+	ingredients := data["recipeIngredient"]
+	if ingredients == nil {
+		// the property before recipeIngredient
+		ingredients = data["ingredients"]
+	}
+
+	for _, value := range schemaValues(ingredients) {
+		if ingredient := schemaText(value); ingredient != "" {
+			rec.Info["ingredients"] = append(rec.Info["ingredients"], ingredient)
+		}
+	}
+
+	if preparation := schemaInstructions(data["recipeInstructions"]); len(preparation) > 0 {
+		rec.Info["preparation"] = preparation
+	}
+
+	images := []string{}
+	for _, value := range schemaValues(data["image"]) {
+		imageURL := ""
+		switch image := value.(type) {
+		case string:
+			imageURL = image
+		case map[string]interface{}:
+			// an ImageObject
+			imageURL, _ = firstValue(image["url"]).(string)
+			if imageURL == "" {
+				imageURL, _ = firstValue(image["contentUrl"]).(string)
+			}
+		}
+
+		if imageURL = strings.TrimSpace(imageURL); imageURL != "" {
+			images = append(images, imageURL)
+		}
+	}
+
+	return rec, images
+}
+
+// schemaValues flattens a property, which can be a single value or a list
+func schemaValues(value interface{}) []interface{} {
+	switch values := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		flat := []interface{}{}
+		for _, item := range values {
+			flat = append(flat, schemaValues(item)...)
+		}
+
+		return flat
+	}
+
+	return []interface{}{value}
+}
+
+// firstValue is the first value of a property, nil if it has none
+func firstValue(value interface{}) interface{} {
+	if values := schemaValues(value); len(values) > 0 {
+		return values[0]
+	}
+
+	return nil
+}
+
+// schemaText is the plain text of a value on one line, things like a Person use their name
+func schemaText(value interface{}) string {
+	switch text := value.(type) {
+	case string:
+		return cleanText(text)
+	case float64:
+		return strconv.FormatFloat(text, 'f', -1, 64)
+	case map[string]interface{}:
+		for _, key := range []string{"name", "text", "@value"} {
+			if found := schemaText(firstValue(text[key])); found != "" {
+				return found
+			}
+		}
+	}
+
+	return ""
+}
+
+// schemaLines splits a value into lines of plain text, breaking at new lines and the HTML that ends a line
+func schemaLines(value interface{}) []string {
+	text, ok := value.(string)
+	if !ok {
+		if text = schemaText(value); text == "" {
+			return nil
+		}
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(breakRegexp.ReplaceAllString(text, "\n"), "\n") {
+		if line = cleanText(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// schemaInstructions are the lines of the preparation, from text, HowToSteps or HowToSections of steps
+func schemaInstructions(value interface{}) []string {
+	lines := []string{}
+	for _, item := range schemaValues(value) {
+		step, ok := item.(map[string]interface{})
+		if !ok {
+			lines = append(lines, schemaLines(item)...)
+			continue
+		}
+
+		if steps, exists := step["itemListElement"]; exists {
+			// a HowToSection starts with its name
+			if name := schemaText(firstValue(step["name"])); name != "" {
+				lines = append(lines, name+":")
+			}
+
+			lines = append(lines, schemaInstructions(steps)...)
+			continue
+		}
+
+		text := firstValue(step["text"])
+		if text == nil {
+			text = firstValue(step["name"])
+		}
+
+		lines = append(lines, schemaLines(text)...)
+	}
+
+	return lines
+}
+
+// schemaYield is how many the recipe serves, sites often list a bare number before the text of it
+func schemaYield(value interface{}) string {
+	yields := []string{}
+	for _, item := range schemaValues(value) {
+		if yield := schemaText(item); yield != "" {
+			yields = append(yields, yield)
+		}
+	}
+
+	for _, yield := range yields {
+		if _, err := strconv.ParseFloat(yield, 64); err != nil {
+			return yield
+		}
+	}
+
+	if len(yields) > 0 {
+		return yields[0]
+	}
+
+	return ""
+}
+
+// cleanText removes HTML tags and entities from text and puts it on one line
+func cleanText(text string) string {
+	text = html.UnescapeString(tagRegexp.ReplaceAllString(text, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
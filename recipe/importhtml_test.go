@@ -0,0 +1,137 @@
+package recipe
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const jsonLDPage = `<!doctype html>
+<html><head>
+<script type="application/ld+json">{ broken</script>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+	{"@type": "WebPage", "name": "Ignored"},
+	{
+		"@type": ["Recipe", "NewsArticle"],
+		"name": "Lemon &amp; Poppy Seed Muffins",
+		"author": [{"@type": "Person", "name": "Ada"}, "Grace"],
+		"inLanguage": "en-GB",
+		"keywords": "muffins, lemon",
+		"recipeCategory": ["Breakfast", "Muffins"],
+		"recipeYield": ["12", "12 muffins"],
+		"recipeIngredient": ["2 cups flour", "1 <b>lemon</b>", " "],
+		"recipeInstructions": [
+			{"@type": "HowToSection", "name": "Batter", "itemListElement": [
+				{"@type": "HowToStep", "text": "Mix the dry ingredients."},
+				{"@type": "HowToStep", "text": "Zest the lemon.<br>Stir it in."}
+			]},
+			{"@type": "HowToStep", "name": "Bake for 20 minutes."}
+		],
+		"image": {"@type": "ImageObject", "url": "https://example.com/muffins.jpg"}
+	}
+]}
+</script>
+</head><body><h1>Lemon Muffins</h1></body></html>`
+
+const microdataPage = `<!doctype html>
+<html><body>
+<div itemscope itemtype="http://schema.org/Recipe">
+	<h1 itemprop="name">Tomato   Soup</h1>
+	<img itemprop="image" src="soup_files/soup.png" alt="">
+	<span itemprop="author" itemscope itemtype="http://schema.org/Person">By <span itemprop="name">Jo</span></span>
+	<meta itemprop="recipeYield" content="4 bowls">
+	<ul>
+		<li itemprop="recipeIngredient">6 tomatoes</li>
+		<li itemprop="recipeIngredient">1 onion</li>
+	</ul>
+	<ol itemprop="recipeInstructions">
+		<li>Chop everything.</li>
+		<li>Simmer for <time datetime="PT30M">30 minutes</time>.</li>
+	</ol>
+</div>
+</body></html>`
+
+func TestParseHTMLJSONLD(t *testing.T) {
+	rec, images, err := ParseHTML(strings.NewReader(jsonLDPage))
+	if err != nil {
+		t.Fatal("Failed to parse JSON-LD", err)
+	}
+
+	if rec.Title != "Lemon & Poppy Seed Muffins" {
+		t.Errorf("Title != \"Lemon & Poppy Seed Muffins\": \"%s\"", rec.Title)
+	}
+
+	if rec.Author != "Ada, Grace" {
+		t.Errorf("Author != \"Ada, Grace\": \"%s\"", rec.Author)
+	}
+
+	if rec.Language != "en" {
+		t.Errorf("Language != \"en\": \"%s\"", rec.Language)
+	}
+
+	if expected := []string{"muffins", "lemon", "Breakfast"}; !reflect.DeepEqual(rec.Tags, expected) {
+		t.Errorf("Tags != %v: %v", expected, rec.Tags)
+	}
+
+	expected := map[string][]string{
+		"serves":      {"12 muffins"},
+		"ingredients": {"2 cups flour", "1 lemon"},
+		"preparation": {"Batter:", "Mix the dry ingredients.", "Zest the lemon.", "Stir it in.", "Bake for 20 minutes."},
+	}
+
+	if !reflect.DeepEqual(rec.Info, expected) {
+		t.Errorf("Info != %v: %v", expected, rec.Info)
+	}
+
+	if !reflect.DeepEqual(images, []string{"https://example.com/muffins.jpg"}) {
+		t.Errorf("Expected the ImageObject URL, got %v", images)
+	}
+}
+
+func TestParseHTMLMicrodata(t *testing.T) {
+	rec, images, err := ParseHTML(strings.NewReader(microdataPage))
+	if err != nil {
+		t.Fatal("Failed to parse microdata", err)
+	}
+
+	if rec.Title != "Tomato Soup" {
+		t.Errorf("Title != \"Tomato Soup\": \"%s\"", rec.Title)
+	}
+
+	// the name of the author is not the name of the recipe
+	if rec.Author != "Jo" {
+		t.Errorf("Author != \"Jo\": \"%s\"", rec.Author)
+	}
+
+	expected := map[string][]string{
+		"serves":      {"4 bowls"},
+		"ingredients": {"6 tomatoes", "1 onion"},
+		"preparation": {"Chop everything.", "Simmer for 30 minutes."},
+	}
+
+	if !reflect.DeepEqual(rec.Info, expected) {
+		t.Errorf("Info != %v: %v", expected, rec.Info)
+	}
+
+	if !reflect.DeepEqual(images, []string{"soup_files/soup.png"}) {
+		t.Errorf("Expected the image src, got %v", images)
+	}
+}
+
+func TestParseHTMLNoRecipe(t *testing.T) {
+	for _, page := range []string{
+		"<html><body><p>Just a page</p></body></html>",
+		`<script type="application/ld+json">{"@type": "Article", "name": "News"}</script>`,
+	} {
+		_, _, err := ParseHTML(strings.NewReader(page))
+		if err != ErrNoRecipeData {
+			t.Errorf("Expected ErrNoRecipeData for %s, got %v", page, err)
+		}
+	}
+
+	_, _, err := ParseHTML(strings.NewReader(`<script type="application/ld+json">{"@type": "Recipe"}</script>`))
+	if err == nil {
+		t.Error("Expected an error for a recipe without a name")
+	}
+}
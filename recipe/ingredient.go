@@ -0,0 +1,248 @@
+package recipe
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// quantityRegexp finds leading quantities such as 2, 1.5, 1/2, 2 1/2, ½ or 2-3
+	quantityRegexp = regexp.MustCompile(`^((?:\d+(?:\.\d+)?\s+)?\d+/\d+|\d*\s*[¼½¾⅓⅔⅛]|\d+(?:\.\d+)?)(?:\s*(?:-|to)\s*(?:\d+/\d+|\d+(?:\.\d+)?))?\s*`)
+
+	// parentheticalRegexp finds notes like (8 oz) or (optional)
+	parentheticalRegexp = regexp.MustCompile(`\([^)]*\)`)
+
+	// unicodeFractions maps unicode vulgar fractions to their values
+	unicodeFractions = map[rune]float64{
+		'¼': 0.25,
+		'½': 0.5,
+		'¾': 0.75,
+		'⅓': 1.0 / 3,
+		'⅔': 2.0 / 3,
+		'⅛': 0.125,
+	}
+
+	// units maps every known unit spelling to its normal name
+	units = map[string]string{
+		"c":           "cup",
+		"cup":         "cup",
+		"cups":        "cup",
+		"t":           "teaspoon",
+		"tsp":         "teaspoon",
+		"teaspoon":    "teaspoon",
+		"teaspoons":   "teaspoon",
+		"tbs":         "tablespoon",
+		"tbsp":        "tablespoon",
+		"tablespoon":  "tablespoon",
+		"tablespoons": "tablespoon",
+		"oz":          "ounce",
+		"ounce":       "ounce",
+		"ounces":      "ounce",
+		"lb":          "pound",
+		"lbs":         "pound",
+		"pound":       "pound",
+		"pounds":      "pound",
+		"g":           "gram",
+		"gram":        "gram",
+		"grams":       "gram",
+		"kg":          "kilogram",
+		"kilogram":    "kilogram",
+		"kilograms":   "kilogram",
+		"ml":          "milliliter",
+		"milliliter":  "milliliter",
+		"milliliters": "milliliter",
+		"l":           "liter",
+		"liter":       "liter",
+		"liters":      "liter",
+		"pt":          "pint",
+		"pint":        "pint",
+		"pints":       "pint",
+		"qt":          "quart",
+		"quart":       "quart",
+		"quarts":      "quart",
+		"gal":         "gallon",
+		"gallon":      "gallon",
+		"gallons":     "gallon",
+		"can":         "can",
+		"cans":        "can",
+		"package":     "package",
+		"packages":    "package",
+		"pkg":         "package",
+		"stick":       "stick",
+		"sticks":      "stick",
+		"clove":       "clove",
+		"cloves":      "clove",
+		"pinch":       "pinch",
+		"dash":        "dash",
+		"slice":       "slice",
+		"slices":      "slice",
+		"bunch":       "bunch",
+		"bunches":     "bunch",
+		"head":        "head",
+		"heads":       "head",
+	}
+
+	// nameSuffixes are trailing notes that are not part of an ingredient name
+	nameSuffixes = []string{
+		"to taste",
+		"as needed",
+		"for garnish",
+		"optional",
+	}
+)
+
+// Ingredient is a single parsed line of a recipe's ingredients
+type Ingredient struct {
+	// the original line from the recipe
+	Line string `json:"line"`
+	// amount of the ingredient, 0 if unknown
+	Quantity float64 `json:"quantity,omitempty"`
+	// normalized unit of the quantity, empty if unknown
+	Unit string `json:"unit,omitempty"`
+	// lowercase name of the ingredient without preparation notes
+	Name string `json:"name"`
+}
+
+// ParseIngredient splits an ingredient line into its quantity, unit and name
+func ParseIngredient(line string) Ingredient {
+	ingredient := Ingredient{Line: line}
+
+	rest := strings.TrimSpace(parentheticalRegexp.ReplaceAllString(line, " "))
+	rest = strings.TrimLeft(rest, "-*•· \t")
+
+	if match := quantityRegexp.FindStringSubmatch(rest); match != nil && strings.TrimSpace(match[0]) != "" {
+		ingredient.Quantity = parseQuantity(match[1])
+		rest = rest[len(match[0]):]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) > 1 {
+		unit := strings.ToLower(strings.TrimSuffix(fields[0], "."))
+		if normalUnit, exists := units[unit]; exists {
+			ingredient.Unit = normalUnit
+			fields = fields[1:]
+		}
+	}
+
+	name := strings.ToLower(strings.Join(fields, " "))
+	name = strings.TrimPrefix(name, "of ")
+
+	// drop preparation notes such as ", chopped"
+	if index := strings.Index(name, ","); index >= 0 {
+		name = name[:index]
+	}
+
+	for _, suffix := range nameSuffixes {
+		name = strings.TrimSpace(strings.TrimSuffix(name, suffix))
+	}
+
+	ingredient.Name = strings.TrimSpace(name)
+
+	return ingredient
+}
+
+// parseQuantity converts quantity text like 2 1/2 or ½ to a number
+func parseQuantity(text string) (quantity float64) {
+	for _, part := range strings.Fields(text) {
+		for _, char := range part {
+			if fraction, exists := unicodeFractions[char]; exists {
+				quantity += fraction
+				part = strings.Replace(part, string(char), "", 1)
+			}
+		}
+
+		if part == "" {
+			continue
+		}
+
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			numerator, err := strconv.ParseFloat(part[:slash], 64)
+			if err != nil {
+				continue
+			}
+
+			denominator, err := strconv.ParseFloat(part[slash+1:], 64)
+			if err != nil || denominator == 0 {
+				continue
+			}
+
+			quantity += numerator / denominator
+			continue
+		}
+
+		number, err := strconv.ParseFloat(part, 64)
+		if err == nil {
+			quantity += number
+		}
+	}
+
+	return
+}
+
+// Words are the normalized words of the ingredient name
+func (i Ingredient) Words() []string {
+	return normalWords(i.Name)
+}
+
+// Matches determines if every word of item is in the ingredient name
+// "butter" matches "unsalted butter" and "egg" matches "eggs"
+func (i Ingredient) Matches(item string) bool {
+	itemWords := normalWords(item)
+	if len(itemWords) == 0 {
+		return false
+	}
+
+	words := make(map[string]bool)
+	for _, word := range i.Words() {
+		words[word] = true
+	}
+
+	for _, word := range itemWords {
+		if !words[word] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalWords lowercases and singularizes the words of text
+func normalWords(text string) (words []string) {
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		words = append(words, singular(word))
+	}
+
+	return
+}
+
+// singular is a very rough english singularization for matching
+func singular(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 4 && (strings.HasSuffix(word, "oes") || strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes")):
+		return word[:len(word)-2]
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return word[:len(word)-1]
+	}
+
+	return word
+}
+
+// Ingredients parses every line of the recipe's ingredients
+func (r *Recipe) Ingredients() (ingredients []Ingredient) {
+	for _, line := range r.Info["ingredients"] {
+		ingredient := ParseIngredient(line)
+		if ingredient.Name == "" {
+			continue
+		}
+
+		ingredients = append(ingredients, ingredient)
+	}
+
+	return
+}
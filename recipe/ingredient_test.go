@@ -0,0 +1,50 @@
+package recipe
+
+import "testing"
+
+func TestParseIngredient(t *testing.T) {
+	expected := map[string]Ingredient{
+		"2 cups all-purpose flour, sifted":    {Quantity: 2, Unit: "cup", Name: "all-purpose flour"},
+		"2 1/2 tsp. baking powder":            {Quantity: 2.5, Unit: "teaspoon", Name: "baking powder"},
+		"½ lb butter":                         {Quantity: 0.5, Unit: "pound", Name: "butter"},
+		"1 (8 oz) package cream cheese":       {Quantity: 1, Unit: "package", Name: "cream cheese"},
+		"3-4 eggs":                            {Quantity: 3, Name: "eggs"},
+		"Salt and pepper to taste":            {Name: "salt and pepper"},
+		"- 1 cup of sugar":                    {Quantity: 1, Unit: "cup", Name: "sugar"},
+		"1 onion":                             {Quantity: 1, Name: "onion"},
+		"1/3 cup chopped pecans (optional)":   {Quantity: 1.0 / 3, Unit: "cup", Name: "chopped pecans"},
+		"Zest of 1 lemon":                     {Name: "zest of 1 lemon"},
+		"2 tablespoons heavy cream, to taste": {Quantity: 2, Unit: "tablespoon", Name: "heavy cream"},
+	}
+
+	for line, want := range expected {
+		got := ParseIngredient(line)
+		if got.Line != line {
+			t.Errorf("Line != \"%s\": \"%s\"", line, got.Line)
+		}
+
+		if got.Quantity != want.Quantity || got.Unit != want.Unit || got.Name != want.Name {
+			t.Errorf("\"%s\" parsed to %+v, expected %+v", line, got, want)
+		}
+	}
+}
+
+func TestIngredientMatches(t *testing.T) {
+	ingredient := ParseIngredient("3 large eggs, beaten")
+	for _, item := range []string{"egg", "Eggs", "large egg"} {
+		if !ingredient.Matches(item) {
+			t.Errorf("\"%s\" should match \"%s\"", item, ingredient.Name)
+		}
+	}
+
+	for _, item := range []string{"", "egg whites", "bacon"} {
+		if ingredient.Matches(item) {
+			t.Errorf("\"%s\" should not match \"%s\"", item, ingredient.Name)
+		}
+	}
+
+	ingredient = ParseIngredient("4 ripe tomatoes")
+	if !ingredient.Matches("tomato") {
+		t.Errorf("\"tomato\" should match \"%s\"", ingredient.Name)
+	}
+}
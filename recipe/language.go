@@ -0,0 +1,61 @@
+package recipe
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultLanguage is used when the language of a recipe is unknown
+const DefaultLanguage = "en"
+
+// languageStopWords are common words that identify a language
+var languageStopWords = map[string][]string{
+	"en": {"the", "and", "with", "of", "to", "in", "until", "into", "for", "add", "or", "cup", "cups", "minutes"},
+	"de": {"der", "die", "das", "und", "mit", "für", "in", "bis", "den", "dem", "ein", "eine", "zucker", "minuten"},
+	"it": {"il", "la", "le", "di", "e", "con", "per", "del", "della", "un", "una", "fino", "sale", "minuti"},
+	"es": {"el", "la", "los", "las", "de", "y", "con", "para", "del", "un", "una", "hasta", "taza", "minutos"},
+}
+
+// NormalizeLanguage converts a language tag like "de-DE" to "de"
+func NormalizeLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if index := strings.IndexAny(language, "-_"); index >= 0 {
+		language = language[:index]
+	}
+
+	return language
+}
+
+// DetectLanguage guesses the language of text by counting common words
+// an empty string is returned when there is not a clear winner
+func DetectLanguage(text string) string {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for language, stopWords := range languageStopWords {
+			for _, stopWord := range stopWords {
+				if word == stopWord {
+					counts[language]++
+					break
+				}
+			}
+		}
+	}
+
+	best, bestCount, secondCount := "", 0, 0
+	for language, count := range counts {
+		if count > bestCount {
+			best, bestCount, secondCount = language, count, bestCount
+		} else if count > secondCount {
+			secondCount = count
+		}
+	}
+
+	// a handful of words must be found and clearly beat every other language
+	if bestCount < 5 || float64(bestCount) < float64(secondCount)*1.5 {
+		return ""
+	}
+
+	return best
+}
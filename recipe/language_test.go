@@ -0,0 +1,36 @@
+package recipe
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	expected := map[string]string{
+		"Mix the flour and the sugar with the butter, then bake for 30 minutes until golden.":                 "en",
+		"Die Butter mit dem Zucker schaumig rühren und das Mehl mit den Eiern unterheben.":                    "de",
+		"Mescolare la farina con il burro e lo zucchero, poi cuocere per 30 minuti fino a doratura.":          "it",
+		"Mezclar la harina con la mantequilla y el azúcar, luego hornear hasta que los bordes estén dorados.": "es",
+		"Butter":     "",
+		"":           "",
+		"Pound cake": "",
+	}
+
+	for text, language := range expected {
+		if detected := DetectLanguage(text); detected != language {
+			t.Errorf("DetectLanguage(\"%s\") != \"%s\": \"%s\"", text, language, detected)
+		}
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	expected := map[string]string{
+		"en-US": "en",
+		"de_DE": "de",
+		" IT ":  "it",
+		"":      "",
+	}
+
+	for language, normal := range expected {
+		if got := NormalizeLanguage(language); got != normal {
+			t.Errorf("NormalizeLanguage(\"%s\") != \"%s\": \"%s\"", language, normal, got)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package recipe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"io"
+)
+
+const (
+	// exifOrientationTag is the EXIF tag of how the camera was held
+	exifOrientationTag = 0x0112
+
+	// ExifHeaderSize is the most of a JPEG read to find its orientation,
+	// EXIF is in the first segment and segments are at most 64 KB
+	ExifHeaderSize = 128 << 10
+)
+
+// ImageOrientation reads the EXIF orientation of a JPEG or TIFF, 1 if there is none
+func ImageOrientation(data []byte) int {
+	if bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")) {
+		return exifOrientation(data)
+	}
+
+	return JPEGOrientation(bytes.NewReader(data))
+}
+
+// JPEGOrientation reads the EXIF orientation of a JPEG, 1 if there is none
+// 2 through 8 are the mirrored and rotated orientations in the EXIF specification
+func JPEGOrientation(reader io.Reader) int {
+	data := make([]byte, ExifHeaderSize)
+	read, _ := io.ReadFull(reader, data)
+	data = data[:read]
+
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+
+	for offset := 2; offset+4 <= len(data); {
+		if data[offset] != 0xff {
+			return 1
+		}
+
+		marker := data[offset+1]
+		size := int(binary.BigEndian.Uint16(data[offset+2:]))
+		// start of scan, the image data follows so there is no EXIF
+		if marker == 0xda || size < 2 {
+			return 1
+		}
+
+		segment := data[offset+4:]
+		if len(segment) > size-2 {
+			segment = segment[:size-2]
+		}
+
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+
+		offset += 2 + size
+	}
+
+	return 1
+}
+
+// exifOrientation finds the orientation tag in the first IFD of TIFF formatted EXIF data
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < 1 || orientation > 8 {
+			return 1
+		}
+
+		return orientation
+	}
+
+	return 1
+}
+
+// OrientImage transforms img so it is upright for the EXIF orientation
+func OrientImage(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	// orientations 5 through 8 are turned on their side
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	// draw has a fast path to RGBA, which makes the pixels easy to copy
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	oriented := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < src.Rect.Dy(); y++ {
+		for x := 0; x < src.Rect.Dx(); x++ {
+			destX, destY := x, y
+			switch orientation {
+			case 2:
+				destX = width - 1 - x
+			case 3:
+				destX, destY = width-1-x, height-1-y
+			case 4:
+				destY = height - 1 - y
+			case 5:
+				destX, destY = y, x
+			case 6:
+				destX, destY = width-1-y, x
+			case 7:
+				destX, destY = width-1-y, height-1-x
+			case 8:
+				destX, destY = y, height-1-x
+			}
+
+			srcOffset := src.PixOffset(x, y)
+			copy(oriented.Pix[oriented.PixOffset(destX, destY):], src.Pix[srcOffset:srcOffset+4])
+		}
+	}
+
+	return oriented
+}
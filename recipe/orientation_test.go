@@ -0,0 +1,130 @@
+package recipe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// exifJPEG adds an EXIF segment with the orientation to a JPEG
+func exifJPEG(t *testing.T, img image.Image, orientation uint16, order binary.ByteOrder) []byte {
+	buf := new(bytes.Buffer)
+	err := jpeg.Encode(buf, img, nil)
+	if err != nil {
+		t.Fatal("Failed to encode image", err)
+	}
+
+	tiff := new(bytes.Buffer)
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+
+	binary.Write(tiff, order, uint16(42))
+	binary.Write(tiff, order, uint32(8))
+	// one IFD entry, a SHORT with a count of one
+	binary.Write(tiff, order, uint16(1))
+	binary.Write(tiff, order, uint16(exifOrientationTag))
+	binary.Write(tiff, order, uint16(3))
+	binary.Write(tiff, order, uint32(1))
+	binary.Write(tiff, order, orientation)
+	binary.Write(tiff, order, uint16(0))
+	binary.Write(tiff, order, uint32(0))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	app1 := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	data := buf.Bytes()
+	output := append([]byte{}, data[:2]...)
+	output = append(output, app1...)
+	output = append(output, segment...)
+
+	return append(output, data[2:]...)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 16, 8))
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for orientation := uint16(1); orientation <= 8; orientation++ {
+			data := exifJPEG(t, img, orientation, order)
+			if found := JPEGOrientation(bytes.NewReader(data)); found != int(orientation) {
+				t.Errorf("%s: JPEGOrientation != %d: %d", order, orientation, found)
+			}
+		}
+	}
+
+	plain := new(bytes.Buffer)
+	jpeg.Encode(plain, img, nil)
+	if found := JPEGOrientation(bytes.NewReader(plain.Bytes())); found != 1 {
+		t.Errorf("Expected 1 without EXIF, got %d", found)
+	}
+
+	if found := JPEGOrientation(bytes.NewReader([]byte("not a jpeg"))); found != 1 {
+		t.Errorf("Expected 1 for data that is not a JPEG, got %d", found)
+	}
+}
+
+func TestImageOrientation(t *testing.T) {
+	data := exifJPEG(t, image.NewGray(image.Rect(0, 0, 16, 8)), 3, binary.LittleEndian)
+	if found := ImageOrientation(data); found != 3 {
+		t.Errorf("Expected 3 for the JPEG, got %d", found)
+	}
+
+	// TIFF files have their orientation in their own first IFD
+	tiff := new(bytes.Buffer)
+	tiff.WriteString("MM")
+	binary.Write(tiff, binary.BigEndian, uint16(42))
+	binary.Write(tiff, binary.BigEndian, uint32(8))
+	binary.Write(tiff, binary.BigEndian, uint16(1))
+	binary.Write(tiff, binary.BigEndian, uint16(exifOrientationTag))
+	binary.Write(tiff, binary.BigEndian, uint16(3))
+	binary.Write(tiff, binary.BigEndian, uint32(1))
+	binary.Write(tiff, binary.BigEndian, uint16(8))
+	binary.Write(tiff, binary.BigEndian, uint16(0))
+	binary.Write(tiff, binary.BigEndian, uint32(0))
+
+	if found := ImageOrientation(tiff.Bytes()); found != 8 {
+		t.Errorf("Expected 8 for the TIFF, got %d", found)
+	}
+}
+
+func TestOrientImage(t *testing.T) {
+	// a 3x2 image with a red top left corner
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	img.Set(0, 0, red)
+
+	// where the red corner ends up for each orientation
+	tests := map[int]image.Point{
+		1: {0, 0},
+		2: {2, 0},
+		3: {2, 1},
+		4: {0, 1},
+		5: {0, 0},
+		6: {1, 0},
+		7: {1, 2},
+		8: {0, 2},
+	}
+
+	for orientation, expected := range tests {
+		oriented := OrientImage(img, orientation)
+		width, height := 3, 2
+		if orientation >= 5 {
+			width, height = 2, 3
+		}
+
+		if oriented.Bounds().Dx() != width || oriented.Bounds().Dy() != height {
+			t.Errorf("Orientation %d: expected %dx%d, got %v", orientation, width, height, oriented.Bounds())
+			continue
+		}
+
+		if oriented.At(expected.X, expected.Y) != color.Color(red) {
+			t.Errorf("Orientation %d: expected red at %v", orientation, expected)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package recipe
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"io"
+	"io/ioutil"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	// decoders for every scan format
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// hashSamples is how many pixels across and down each cell of the hash grid samples
+	hashSamples = 8
+)
+
+// hashSlots limits how many scans are decoded at once, phone photos take a lot of memory
+var hashSlots = make(chan struct{}, runtime.NumCPU())
+
+// scanHashes remembers the hashes of scans by path, so a scan is only decoded
+// again when it changes, LoadScanHashes and SaveScanHashes keep it between runs
+var scanHashes = struct {
+	sync.Mutex
+	hashes map[string]scanHash
+}{hashes: make(map[string]scanHash)}
+
+// scanHash is the hash of a scan as it was when it was hashed
+type scanHash struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Hash    uint64    `json:"hash"`
+	// the scan could not be decoded, so it has no hash
+	Failed bool `json:"failed,omitempty"`
+}
+
+// ImageHash computes a perceptual difference hash of an upright image
+// the image is shrunk to 9x8 gray cells and each bit is whether a cell is
+// darker than the one to its right, so resized or recompressed copies of an
+// image have hashes that differ by only a few bits
+func ImageHash(reader io.Reader) (uint64, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return 0, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	// sample evenly across the image, reading every pixel of a photo is slow
+	// the samples are turned upright rather than the whole photo
+	orientation := ImageOrientation(data)
+	width, height := 9*hashSamples, 8*hashSamples
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	bounds := img.Bounds()
+	samples := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			samples.Set(x, y, img.At(bounds.Min.X+x*bounds.Dx()/width, bounds.Min.Y+y*bounds.Dy()/height))
+		}
+	}
+
+	upright := OrientImage(samples, orientation)
+
+	var cells [8][9]uint32
+	for y := range cells {
+		for x := range cells[y] {
+			var sum uint32
+			for sy := 0; sy < hashSamples; sy++ {
+				for sx := 0; sx < hashSamples; sx++ {
+					r, g, b, _ := upright.At(x*hashSamples+sx, y*hashSamples+sy).RGBA()
+					sum += (299*r + 587*g + 114*b) / 1000
+				}
+			}
+
+			cells[y][x] = sum
+		}
+	}
+
+	var hash uint64
+	for y := range cells {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if cells[y][x] < cells[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash, nil
+}
+
+// HashDistance is the number of bits two image hashes differ by, lower is more alike
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// hashScans hashes every scan that can be decoded, keyed by path
+// scans that have not changed since they were last hashed are not decoded again
+func hashScans(paths []string) map[string]uint64 {
+	hashes := make(map[string]uint64, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		scanHashes.Lock()
+		cached, exists := scanHashes.hashes[path]
+		scanHashes.Unlock()
+
+		if !exists || !cached.ModTime.Equal(info.ModTime()) || cached.Size != info.Size() {
+			hashSlots <- struct{}{}
+			hash, err := hashFile(path)
+			<-hashSlots
+
+			cached = scanHash{
+				ModTime: info.ModTime(),
+				Size:    info.Size(),
+				Hash:    hash,
+				Failed:  err != nil,
+			}
+
+			scanHashes.Lock()
+			scanHashes.hashes[path] = cached
+			scanHashes.Unlock()
+		}
+
+		if !cached.Failed {
+			hashes[path] = cached.Hash
+		}
+	}
+
+	return hashes
+}
+
+// LoadScanHashes adds the hashes saved by SaveScanHashes at path to the ones remembered
+func LoadScanHashes(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	hashes := make(map[string]scanHash)
+	err = json.Unmarshal(data, &hashes)
+	if err != nil {
+		return err
+	}
+
+	scanHashes.Lock()
+	defer scanHashes.Unlock()
+
+	for scanPath, hash := range hashes {
+		scanHashes.hashes[scanPath] = hash
+	}
+
+	return nil
+}
+
+// SaveScanHashes saves the remembered hashes of scans that still exist to path
+func SaveScanHashes(path string) error {
+	scanHashes.Lock()
+	hashes := make(map[string]scanHash, len(scanHashes.hashes))
+	for scanPath, hash := range scanHashes.hashes {
+		hashes[scanPath] = hash
+	}
+	scanHashes.Unlock()
+
+	for scanPath := range hashes {
+		if _, err := os.Stat(scanPath); os.IsNotExist(err) {
+			delete(hashes, scanPath)
+		}
+	}
+
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return err
+	}
+
+	// write a temporary file first, so a crash does not leave half of the hashes
+	file, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+	}
+
+	return err
+}
+
+// hashFile hashes the image at path
+func hashFile(path string) (uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer file.Close()
+
+	return ImageHash(file)
+}
@@ -0,0 +1,255 @@
+package recipe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCard draws a card with dark lines of text at the given size
+func testCard(width, height int, flip bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// lines are darker toward the right, or the left when flipped
+			shade := uint8(255 - 200*x/width)
+			if flip {
+				shade = uint8(55 + 200*x/width)
+			}
+
+			if (y*10/height)%2 == 1 {
+				shade /= 2
+			}
+
+			img.Set(x, y, color.Gray{Y: shade})
+		}
+	}
+
+	return img
+}
+
+func hashImage(t *testing.T, img image.Image, encode func(*bytes.Buffer, image.Image) error) uint64 {
+	buf := new(bytes.Buffer)
+	err := encode(buf, img)
+	if err != nil {
+		t.Fatal("Failed to encode image", err)
+	}
+
+	hash, err := ImageHash(buf)
+	if err != nil {
+		t.Fatal("Failed to hash image", err)
+	}
+
+	return hash
+}
+
+func TestImageHash(t *testing.T) {
+	encodeJPEG := func(buf *bytes.Buffer, img image.Image) error {
+		return jpeg.Encode(buf, img, &jpeg.Options{Quality: 40})
+	}
+
+	encodePNG := func(buf *bytes.Buffer, img image.Image) error {
+		return png.Encode(buf, img)
+	}
+
+	original := hashImage(t, testCard(900, 600, false), encodePNG)
+	smaller := hashImage(t, testCard(300, 200, false), encodeJPEG)
+	flipped := hashImage(t, testCard(900, 600, true), encodePNG)
+
+	if distance := HashDistance(original, smaller); distance > 6 {
+		t.Errorf("Expected a resized and recompressed copy to be close, distance %d", distance)
+	}
+
+	if distance := HashDistance(original, flipped); distance < 20 {
+		t.Errorf("Expected a different image to be far, distance %d", distance)
+	}
+
+	if _, err := ImageHash(bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Error("Expected an error hashing something that is not an image")
+	}
+}
+
+func TestImageHashOrientation(t *testing.T) {
+	upright := hashImage(t, testCard(900, 600, false), func(buf *bytes.Buffer, img image.Image) error {
+		return png.Encode(buf, img)
+	})
+
+	// a phone stores the photo sideways with the orientation to turn it upright
+	sideways := OrientImage(testCard(900, 600, false), 8)
+	oriented, err := ImageHash(bytes.NewReader(exifJPEG(t, sideways, 6, binary.BigEndian)))
+	if err != nil {
+		t.Fatal("Failed to hash image", err)
+	}
+
+	unoriented := hashImage(t, sideways, func(buf *bytes.Buffer, img image.Image) error {
+		return jpeg.Encode(buf, img, nil)
+	})
+
+	if distance := HashDistance(upright, oriented); distance > 6 {
+		t.Errorf("Expected the sideways photo to be hashed upright, distance %d", distance)
+	}
+
+	if HashDistance(upright, unoriented) <= HashDistance(upright, oriented) {
+		t.Error("Expected the photo without its orientation to be further from upright")
+	}
+}
+
+func TestParseFilesScanHashes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-hashes")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	docxPath := writeTestDocx(t, dir, []string{"Family Recipe", "Toast", "Ingredients", "Bread"})
+	buf := new(bytes.Buffer)
+	err = png.Encode(buf, testCard(90, 60, false))
+	if err != nil {
+		t.Fatal("Failed to encode image", err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "front.png"), buf.Bytes(), 0644)
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(dir, "broken.jpg"), []byte("broken"), 0644)
+	}
+
+	if err != nil {
+		t.Fatal("Failed to write scans", err)
+	}
+
+	rec := &Recipe{DocxPath: docxPath}
+	err = rec.ParseFiles()
+	if err != nil {
+		t.Fatal("Failed to parse files", err)
+	}
+
+	if len(rec.ScanPaths) != 2 {
+		t.Fatalf("Expected 2 scans, got %v", rec.ScanPaths)
+	}
+
+	if _, exists := rec.ScanHashes[filepath.Join(dir, "front.png")]; !exists {
+		t.Error("Expected a hash for front.png")
+	}
+
+	if _, exists := rec.ScanHashes[filepath.Join(dir, "broken.jpg")]; exists {
+		t.Error("Expected no hash for a scan that does not decode")
+	}
+}
+
+func TestScanHashCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-hashes")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	docxPath := writeTestDocx(t, dir, []string{"Family Recipe", "Toast", "Ingredients", "Bread"})
+	buf := new(bytes.Buffer)
+	err = png.Encode(buf, testCard(90, 60, false))
+	if err != nil {
+		t.Fatal("Failed to encode image", err)
+	}
+
+	front := filepath.Join(dir, "front.png")
+	broken := filepath.Join(dir, "broken.jpg")
+	err = ioutil.WriteFile(front, buf.Bytes(), 0644)
+	if err == nil {
+		err = ioutil.WriteFile(broken, []byte("broken"), 0644)
+	}
+
+	if err != nil {
+		t.Fatal("Failed to write scans", err)
+	}
+
+	parse := func() *Recipe {
+		rec := &Recipe{DocxPath: docxPath}
+		err := rec.ParseFiles()
+		if err != nil {
+			t.Fatal("Failed to parse files", err)
+		}
+
+		return rec
+	}
+
+	hash := parse().ScanHashes[front]
+
+	// hashes are kept between runs
+	hashesPath := filepath.Join(dir, "hashes.json")
+	err = SaveScanHashes(hashesPath)
+	if err != nil {
+		t.Fatal("Failed to save hashes", err)
+	}
+
+	scanHashes.Lock()
+	scanHashes.hashes = make(map[string]scanHash)
+	scanHashes.Unlock()
+
+	err = LoadScanHashes(hashesPath)
+	if err != nil {
+		t.Fatal("Failed to load hashes", err)
+	}
+
+	scanHashes.Lock()
+	cached, exists := scanHashes.hashes[front]
+	failed := scanHashes.hashes[broken].Failed
+	// an unchanged scan is not decoded again, so this hash is used
+	cached.Hash = hash + 1
+	scanHashes.hashes[front] = cached
+	scanHashes.Unlock()
+
+	if !exists || !failed {
+		t.Fatal("Expected the loaded hashes to have front.png and the failed broken.jpg")
+	}
+
+	if cachedHash := parse().ScanHashes[front]; cachedHash != hash+1 {
+		t.Errorf("Expected the cached hash of front.png, got %d", cachedHash)
+	}
+
+	// a changed scan is hashed again
+	modified := time.Now().Add(time.Minute)
+	err = os.Chtimes(front, modified, modified)
+	if err != nil {
+		t.Fatal("Failed to touch front.png", err)
+	}
+
+	if changedHash := parse().ScanHashes[front]; changedHash != hash {
+		t.Errorf("Expected front.png to be hashed again, got %d", changedHash)
+	}
+
+	// deleted scans are not saved
+	err = os.Remove(broken)
+	if err == nil {
+		err = SaveScanHashes(hashesPath)
+	}
+
+	if err != nil {
+		t.Fatal("Failed to save hashes", err)
+	}
+
+	scanHashes.Lock()
+	scanHashes.hashes = make(map[string]scanHash)
+	scanHashes.Unlock()
+
+	err = LoadScanHashes(hashesPath)
+	if err != nil {
+		t.Fatal("Failed to load hashes", err)
+	}
+
+	scanHashes.Lock()
+	_, exists = scanHashes.hashes[broken]
+	scanHashes.Unlock()
+
+	if exists {
+		t.Error("Expected the deleted broken.jpg not to be saved")
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tblyler/goatomic"
 	"github.com/tblyler/recipe-card/doc"
@@ -21,6 +24,17 @@ var ValidCategoriesOrder = []string{
 	"tips",
 }
 
+var (
+	// numberRegexp finds integers and decimals
+	numberRegexp = regexp.MustCompile(`\d+(\.\d+)?`)
+
+	// durationRegexp finds durations like 45 minutes or 1-2 hours
+	durationRegexp = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)(?:\s*(?:-|to)\s*(\d+(?:\.\d+)?))?\s*(minutes?|mins?|hours?|hrs?)\b`)
+
+	// temperatureRegexp finds a temperature and its optional unit
+	temperatureRegexp = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(?:°|degrees?)?\s*([cf])?(?:elsius|ahrenheit)?\b`)
+)
+
 // validCategories defines keys of Info
 var validCategories = map[string]bool{
 	"oven temperature": true,
@@ -37,7 +51,19 @@ type Recipe struct {
 	// FIXME support non-docx
 	DocxPath  string   `json:"docx_path"`
 	ScanPaths []string `json:"scan_paths"`
-	Image     []byte
+	// perceptual hashes of the scans that could be decoded, keyed by path
+	ScanHashes map[string]uint64 `json:"-"`
+	// documents in the recipe's folder, sorted by path
+	Attachments []*Attachment `json:"attachments"`
+	Tags        []string      `json:"tags"`
+	// folders between the recipes path and the recipe's own folder
+	Category string `json:"category"`
+	Author   string `json:"author"`
+	// ISO 639-1 language code of the recipe, such as "en"
+	Language string    `json:"language"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	Image    []byte    `json:"-"`
 }
 
 // Summary outputs a nice summary of Info
@@ -56,11 +82,63 @@ func (r *Recipe) Summary() (output string) {
 	return
 }
 
+// Servings is the first number of people the recipe serves, 0 if unknown
+func (r *Recipe) Servings() float64 {
+	for _, line := range r.Info["serves"] {
+		if match := numberRegexp.FindString(line); match != "" {
+			servings, _ := strconv.ParseFloat(match, 64)
+			return servings
+		}
+	}
+
+	return 0
+}
+
+// OvenTemperature is the first oven temperature in fahrenheit, 0 if unknown
+func (r *Recipe) OvenTemperature() float64 {
+	for _, line := range r.Info["oven temperature"] {
+		match := temperatureRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		temperature, _ := strconv.ParseFloat(match[1], 64)
+		if strings.EqualFold(match[2], "c") {
+			temperature = temperature*9/5 + 32
+		}
+
+		return temperature
+	}
+
+	return 0
+}
+
+// TotalMinutes estimates the total time of the recipe by adding up every
+// duration in the preparation, using the longest time of a range
+func (r *Recipe) TotalMinutes() (minutes float64) {
+	for _, line := range r.Info["preparation"] {
+		for _, match := range durationRegexp.FindAllStringSubmatch(line, -1) {
+			duration, _ := strconv.ParseFloat(match[1], 64)
+			if match[2] != "" {
+				duration, _ = strconv.ParseFloat(match[2], 64)
+			}
+
+			if strings.HasPrefix(strings.ToLower(match[3]), "h") {
+				duration *= 60
+			}
+
+			minutes += duration
+		}
+	}
+
+	return
+}
+
 // ParseFiles for the recipe
 func (r *Recipe) ParseFiles() error {
 	dir := filepath.Dir(r.DocxPath)
 
-	// get a list of recipe scans
+	// get a list of recipe scans and attachments
 	infos, err := ioutil.ReadDir(dir)
 	if err != nil {
 		return err
@@ -71,22 +149,29 @@ func (r *Recipe) ParseFiles() error {
 			continue
 		}
 
-		name := strings.ToLower(info.Name())
-		// FIXME support non-jpeg
-		if !strings.HasSuffix(name, ".jpeg") && !strings.HasSuffix(name, ".jpg") {
+		path := filepath.Join(dir, info.Name())
+		if IsAttachment(info.Name()) {
+			r.Attachments = append(r.Attachments, newAttachment(path, info))
 			continue
 		}
 
-		r.ScanPaths = append(r.ScanPaths, filepath.Join(dir, info.Name()))
+		if !IsScan(info.Name()) {
+			continue
+		}
+
+		r.ScanPaths = append(r.ScanPaths, path)
 	}
 
 	sort.Strings(r.ScanPaths)
+	r.ScanHashes = hashScans(r.ScanPaths)
 
 	file, err := os.Open(r.DocxPath)
 	if err != nil {
 		return err
 	}
 
+	defer file.Close()
+
 	stat, err := file.Stat()
 	if err != nil {
 		return err
@@ -98,6 +183,13 @@ func (r *Recipe) ParseFiles() error {
 	}
 
 	r.Image = docx.Image
+	r.Tags = docx.Properties.KeywordList()
+	r.Author = strings.TrimSpace(docx.Properties.Creator)
+	r.Modified = stat.ModTime()
+	r.Created = docx.Properties.Created
+	if r.Created.IsZero() {
+		r.Created = r.Modified
+	}
 
 	lines, err := docx.Text()
 	if err != nil {
@@ -136,6 +228,47 @@ func (r *Recipe) ParseFiles() error {
 		r.Info[currentGroup] = append(r.Info[currentGroup], line)
 	}
 
+	sidecar, err := ReadSidecar(dir)
+	if err != nil {
+		return err
+	}
+
+	if sidecar.Title != "" {
+		r.Title = sidecar.Title
+	}
+
+	for category, info := range sidecar.Info {
+		if !validCategories[category] {
+			continue
+		}
+
+		if len(info) == 0 {
+			delete(r.Info, category)
+		} else {
+			r.Info[category] = info
+		}
+	}
+
+	// edits in the sidecar are modifications to the recipe too
+	if stat, err := os.Stat(filepath.Join(dir, SidecarFileName)); err == nil && stat.ModTime().After(r.Modified) {
+		r.Modified = stat.ModTime()
+	}
+
+	// an explicit language wins, then the text itself since docx files
+	// usually just have the language of whoever created them
+	r.Language = NormalizeLanguage(sidecar.Language)
+	if r.Language == "" {
+		r.Language = DetectLanguage(r.Summary())
+	}
+
+	if r.Language == "" {
+		r.Language = NormalizeLanguage(docx.Properties.Language)
+	}
+
+	if r.Language == "" {
+		r.Language = DefaultLanguage
+	}
+
 	return nil
 }
 
@@ -163,9 +296,17 @@ func RecipesFromPath(dirPath string) (recipes []*Recipe, err error) {
 			return nil
 		}
 
-		recipes = append(recipes, &Recipe{
+		recipe := &Recipe{
 			DocxPath: path,
-		})
+		}
+
+		// recipes are in their own folder, anything above that is the category
+		category, err := filepath.Rel(dirPath, filepath.Dir(filepath.Dir(path)))
+		if err == nil && category != "." && !strings.HasPrefix(category, "..") {
+			recipe.Category = filepath.ToSlash(category)
+		}
+
+		recipes = append(recipes, recipe)
 
 		return nil
 	})
@@ -0,0 +1,142 @@
+package recipe
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownServings is returned when scaling a recipe that does not say how many it serves
+var ErrUnknownServings = errors.New("the recipe does not say how many it serves")
+
+var (
+	// rangeRegexp finds the separator of a quantity range such as 2-3 or 2 to 3
+	rangeRegexp = regexp.MustCompile(`\s*(?:-|to)\s*`)
+
+	// quantityFractions are the fractions scaled quantities are rounded to
+	quantityFractions = []struct {
+		value float64
+		text  string
+	}{
+		{0, ""},
+		{1.0 / 8, "1/8"},
+		{1.0 / 4, "1/4"},
+		{1.0 / 3, "1/3"},
+		{3.0 / 8, "3/8"},
+		{1.0 / 2, "1/2"},
+		{5.0 / 8, "5/8"},
+		{2.0 / 3, "2/3"},
+		{3.0 / 4, "3/4"},
+		{7.0 / 8, "7/8"},
+		{1, ""},
+	}
+)
+
+// Scale copies the recipe with its servings and ingredient quantities changed to make servings
+func (r *Recipe) Scale(servings float64) (*Recipe, error) {
+	current := r.Servings()
+	if current == 0 {
+		return nil, ErrUnknownServings
+	}
+
+	scaled := *r
+	scaled.Info = make(map[string][]string, len(r.Info))
+	for category, lines := range r.Info {
+		scaled.Info[category] = lines
+	}
+
+	factor := servings / current
+	if lines, exists := r.Info["ingredients"]; exists {
+		ingredients := make([]string, 0, len(lines))
+		for _, line := range lines {
+			ingredients = append(ingredients, ScaleIngredientLine(line, factor))
+		}
+
+		scaled.Info["ingredients"] = ingredients
+	}
+
+	// only the line the servings come from is changed, ranges like 4-6 are scaled together
+	serves := make([]string, 0, len(r.Info["serves"]))
+	scaledServes := false
+	for _, line := range r.Info["serves"] {
+		if !scaledServes && numberRegexp.MatchString(line) {
+			line = numberRegexp.ReplaceAllStringFunc(line, func(number string) string {
+				value, _ := strconv.ParseFloat(number, 64)
+				return FormatQuantity(value * factor)
+			})
+			scaledServes = true
+		}
+
+		serves = append(serves, line)
+	}
+
+	scaled.Info["serves"] = serves
+
+	return &scaled, nil
+}
+
+// ScaleIngredientLine multiplies the leading quantity of an ingredient line by factor
+// lines without a quantity, such as "salt to taste", are left alone
+func ScaleIngredientLine(line string, factor float64) string {
+	rest := strings.TrimLeft(line, "-*•· \t")
+	prefix := line[:len(line)-len(rest)]
+
+	match := quantityRegexp.FindString(rest)
+	quantity := strings.TrimSpace(match)
+	if quantity == "" {
+		return line
+	}
+
+	scaled := ""
+	if index := rangeRegexp.FindStringIndex(quantity); index != nil {
+		scaled = FormatQuantity(parseQuantity(quantity[:index[0]])*factor) +
+			quantity[index[0]:index[1]] +
+			FormatQuantity(parseQuantity(quantity[index[1]:])*factor)
+	} else {
+		scaled = FormatQuantity(parseQuantity(quantity) * factor)
+	}
+
+	// keep whatever space followed the quantity
+	return prefix + scaled + match[len(strings.TrimRightFunc(match, isSpace)):] + rest[len(match):]
+}
+
+// isSpace is used to trim the whitespace that follows a quantity
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+// FormatQuantity writes a quantity the way recipes do, such as 1 1/2
+// quantities are rounded to the nearest eighth or third
+func FormatQuantity(quantity float64) string {
+	whole := math.Floor(quantity)
+	closest := quantityFractions[0]
+	for _, fraction := range quantityFractions {
+		if math.Abs(quantity-whole-fraction.value) < math.Abs(quantity-whole-closest.value) {
+			closest = fraction
+		}
+	}
+
+	if closest.value == 1 {
+		whole++
+	}
+
+	// too small to round to an eighth
+	if whole == 0 && closest.text == "" {
+		if quantity == 0 {
+			return "0"
+		}
+
+		return strconv.FormatFloat(quantity, 'g', 2, 64)
+	}
+
+	switch {
+	case closest.text == "":
+		return strconv.FormatFloat(whole, 'f', 0, 64)
+	case whole == 0:
+		return closest.text
+	}
+
+	return strconv.FormatFloat(whole, 'f', 0, 64) + " " + closest.text
+}
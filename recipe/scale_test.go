@@ -0,0 +1,87 @@
+package recipe
+
+import (
+	"testing"
+)
+
+func TestFormatQuantity(t *testing.T) {
+	tests := map[float64]string{
+		0:       "0",
+		1:       "1",
+		0.5:     "1/2",
+		1.5:     "1 1/2",
+		0.33:    "1/3",
+		2.0 / 3: "2/3",
+		2.97:    "3",
+		0.02:    "0.02",
+		375:     "375",
+	}
+
+	for quantity, expected := range tests {
+		if text := FormatQuantity(quantity); text != expected {
+			t.Errorf("FormatQuantity(%v) != \"%s\": \"%s\"", quantity, expected, text)
+		}
+	}
+}
+
+func TestScaleIngredientLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		factor   float64
+		expected string
+	}{
+		{"2 cups flour", 1.5, "3 cups flour"},
+		{"1/2 cup sugar", 2, "1 cup sugar"},
+		{"- 1 1/2 tsp salt", 0.5, "- 3/4 tsp salt"},
+		{"½ cup milk", 3, "1 1/2 cup milk"},
+		{"2-3 ripe bananas", 2, "4-6 ripe bananas"},
+		{"2 to 3 eggs", 0.5, "1 to 1 1/2 eggs"},
+		{"1 (8 oz) package cream cheese", 2, "2 (8 oz) package cream cheese"},
+		{"salt to taste", 2, "salt to taste"},
+	}
+
+	for _, test := range tests {
+		if scaled := ScaleIngredientLine(test.line, test.factor); scaled != test.expected {
+			t.Errorf("ScaleIngredientLine(\"%s\", %v) != \"%s\": \"%s\"", test.line, test.factor, test.expected, scaled)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	rec := &Recipe{
+		Title: "Pancakes",
+		Info: map[string][]string{
+			"serves":      {"4-6 people"},
+			"ingredients": {"1 cup flour", "2 eggs"},
+			"preparation": {"Fry 2 at a time"},
+		},
+	}
+
+	scaled, err := rec.Scale(8)
+	if err != nil {
+		t.Fatal("Failed to scale", err)
+	}
+
+	expected := map[string][]string{
+		"serves":      {"8-12 people"},
+		"ingredients": {"2 cup flour", "4 eggs"},
+		"preparation": {"Fry 2 at a time"},
+	}
+
+	for category, lines := range expected {
+		for i, line := range lines {
+			if scaled.Info[category][i] != line {
+				t.Errorf("Expected %s line \"%s\", got \"%s\"", category, line, scaled.Info[category][i])
+			}
+		}
+	}
+
+	if rec.Info["ingredients"][0] != "1 cup flour" || rec.Info["serves"][0] != "4-6 people" {
+		t.Error("Scaling changed the original recipe")
+	}
+
+	_, err = (&Recipe{Info: map[string][]string{"ingredients": {"1 egg"}}}).Scale(2)
+	if err != ErrUnknownServings {
+		t.Errorf("Expected ErrUnknownServings, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package recipe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// scanExtensions are the lowercase extensions of image files that are scans of a recipe
+var scanExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".webp": true,
+	".tif":  true,
+	".tiff": true,
+}
+
+// IsScan checks if the file name is an image that is collected as a scan
+func IsScan(name string) bool {
+	return scanExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// AddScan writes a scan into the recipe's folder with the extension, such as ".png", and returns its path
+// the name is made safe for files and numbered when it is taken, so nothing
+// is ever replaced
+// ScanPaths is left alone, copy the recipe with the path added to it
+func (r *Recipe) AddScan(name, extension string, data []byte) (string, error) {
+	if !IsScan(extension) {
+		return "", fmt.Errorf("\"%s\" is not a scan extension", extension)
+	}
+
+	dir := filepath.Dir(r.DocxPath)
+	base := FileName(strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)))
+	if base == "" {
+		base = "scan"
+	}
+
+	for i := 1; ; i++ {
+		fileName := base + extension
+		if i > 1 {
+			fileName = base + "-" + strconv.Itoa(i) + extension
+		}
+
+		path := filepath.Join(dir, fileName)
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+
+		return path, nil
+	}
+}
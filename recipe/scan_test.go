@@ -0,0 +1,78 @@
+package recipe
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddScan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-scan")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	rec := &Recipe{DocxPath: filepath.Join(dir, "recipe.docx")}
+	tests := []struct {
+		name      string
+		extension string
+		expected  string
+	}{
+		{"image.jpg", ".jpg", "image.jpg"},
+		{"image.JPG", ".jpg", "image-2.jpg"},
+		{"../../image.jpeg", ".jpg", "image-3.jpg"},
+		{"", ".jpg", "scan.jpg"},
+		{"front/back?.jpg", ".jpg", "back.jpg"},
+		{"image.png", ".png", "image.png"},
+		{"image.tiff", ".tiff", "image.tiff"},
+	}
+
+	for _, test := range tests {
+		path, err := rec.AddScan(test.name, test.extension, []byte(test.name))
+		if err != nil {
+			t.Fatalf("Failed to add scan \"%s\": %s", test.name, err)
+		}
+
+		if expected := filepath.Join(dir, test.expected); path != expected {
+			t.Errorf("AddScan(\"%s\") path != \"%s\": \"%s\"", test.name, expected, path)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil || string(data) != test.name {
+			t.Errorf("Unexpected data for \"%s\": \"%s\" %v", test.name, data, err)
+		}
+	}
+}
+
+func TestAddScanExtension(t *testing.T) {
+	rec := &Recipe{DocxPath: filepath.Join(os.TempDir(), "recipe.docx")}
+	_, err := rec.AddScan("image.pdf", ".pdf", []byte("%PDF"))
+	if err == nil {
+		t.Error("Expected an error adding a scan that is not an image")
+	}
+}
+
+func TestIsScan(t *testing.T) {
+	tests := map[string]bool{
+		"image.jpg":   true,
+		"IMAGE.JPEG":  true,
+		"card.png":    true,
+		"card.gif":    true,
+		"card.webp":   true,
+		"card.tif":    true,
+		"card.TIFF":   true,
+		"recipe.docx": false,
+		"card.pdf":    false,
+		"card.heic":   false,
+		"jpg":         false,
+	}
+
+	for name, expected := range tests {
+		if IsScan(name) != expected {
+			t.Errorf("IsScan(\"%s\") != %t", name, expected)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package recipe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SidecarFileName is the optional file next to a recipe's docx that
+// overrides what is parsed from the docx
+const SidecarFileName = "recipe.json"
+
+// ErrEditConflict is returned when a recipe's files changed since the version being edited
+var ErrEditConflict = errors.New("the recipe was changed since it was opened for editing")
+
+// Sidecar is the override information for a recipe
+type Sidecar struct {
+	// ISO 639-1 language code of the recipe, such as "de"
+	Language string `json:"language,omitempty"`
+	// title used instead of the one in the docx
+	Title string `json:"title,omitempty"`
+	// lines used instead of the ones in the docx for each category,
+	// a category without lines is removed
+	Info map[string][]string `json:"info,omitempty"`
+}
+
+// ReadSidecar reads the sidecar in dir, an empty sidecar is returned if
+// there is not one
+func ReadSidecar(dir string) (*Sidecar, error) {
+	sidecar := new(Sidecar)
+
+	file, err := os.Open(filepath.Join(dir, SidecarFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sidecar, nil
+		}
+
+		return nil, err
+	}
+
+	defer file.Close()
+
+	err = json.NewDecoder(file).Decode(sidecar)
+	if err != nil {
+		return nil, err
+	}
+
+	return sidecar, nil
+}
+
+// WriteSidecar replaces the sidecar in dir
+// the file is written next to it first so readers never see part of it
+func WriteSidecar(dir string, sidecar *Sidecar) error {
+	data, err := json.MarshalIndent(sidecar, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(dir, "."+SidecarFileName)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(data, '\n'))
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(file.Name(), 0644)
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), filepath.Join(dir, SidecarFileName))
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+	}
+
+	return err
+}
+
+// Version identifies the current state of the recipe's docx and sidecar
+// it changes whenever either of them is modified, the sidecar's contents are
+// included since edits can happen within the resolution of its modification time
+func (r *Recipe) Version() (string, error) {
+	version := ""
+
+	stat, err := os.Stat(r.DocxPath)
+	if err != nil {
+		return "", err
+	}
+
+	version += strconv.FormatInt(stat.ModTime().UnixNano(), 10)
+
+	sidecarPath := filepath.Join(filepath.Dir(r.DocxPath), SidecarFileName)
+	stat, err = os.Stat(sidecarPath)
+	if os.IsNotExist(err) {
+		return version, nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	data, err := ioutil.ReadFile(sidecarPath)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	version += "-" + strconv.FormatInt(stat.ModTime().UnixNano(), 10) + "-" + hex.EncodeToString(sum[:8])
+
+	return version, nil
+}
+
+// Edit saves a new title and category lines for the recipe to its sidecar
+// ErrEditConflict is returned if the files are no longer at version
+// the recipe itself is unchanged, call ParseFiles on a new Recipe to load the edit
+func (r *Recipe) Edit(version string, title string, info map[string][]string) error {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return errors.New("the title must not be empty")
+	}
+
+	currentVersion, err := r.Version()
+	if err != nil {
+		return err
+	}
+
+	if currentVersion != version {
+		return ErrEditConflict
+	}
+
+	dir := filepath.Dir(r.DocxPath)
+	sidecar, err := ReadSidecar(dir)
+	if err != nil {
+		return err
+	}
+
+	sidecar.Title = title
+	sidecar.Info = make(map[string][]string)
+	for _, category := range ValidCategoriesOrder {
+		lines := []string{}
+		for _, line := range info[category] {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+
+		sidecar.Info[category] = lines
+	}
+
+	return WriteSidecar(dir, sidecar)
+}
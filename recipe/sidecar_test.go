@@ -0,0 +1,135 @@
+package recipe
+
+import (
+	"archive/zip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestDocx writes a docx with a paragraph per line into dir
+func writeTestDocx(t *testing.T, dir string, lines []string) string {
+	path := filepath.Join(dir, "recipe.docx")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal("Failed to create docx", err)
+	}
+
+	defer file.Close()
+
+	body := ""
+	for _, line := range lines {
+		body += "<w:p><w:r><w:t>" + line + "</w:t></w:r></w:p>"
+	}
+
+	zipWriter := zip.NewWriter(file)
+	files := map[string]string{
+		"word/document.xml":     "<w:document><w:body>" + body + "</w:body></w:document>",
+		"word/media/image1.jpg": "not really a jpeg",
+	}
+
+	for name, data := range files {
+		fileWriter, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatal("Failed to create zip writer", err)
+		}
+
+		io.WriteString(fileWriter, data)
+	}
+
+	err = zipWriter.Close()
+	if err != nil {
+		t.Fatal("Failed to write docx", err)
+	}
+
+	return path
+}
+
+func TestEdit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-sidecar")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	docxPath := writeTestDocx(t, dir, []string{
+		"Family Recipe",
+		"Pancaks",
+		"Ingredients:",
+		"1 cup flour",
+		"1 egg",
+		"Preparation:",
+		"Fry",
+		"Tips:",
+		"Serve warm",
+	})
+
+	rec := &Recipe{DocxPath: docxPath}
+	err = rec.ParseFiles()
+	if err != nil {
+		t.Fatal("Failed to parse recipe", err)
+	}
+
+	if rec.Title != "Pancaks" {
+		t.Fatalf("Title != \"Pancaks\": \"%s\"", rec.Title)
+	}
+
+	version, err := rec.Version()
+	if err != nil {
+		t.Fatal("Failed to get version", err)
+	}
+
+	err = rec.Edit(version, " Pancakes ", map[string][]string{
+		"ingredients": {"1 cup flour", "", "2 eggs"},
+		"preparation": {"Fry in butter"},
+	})
+	if err != nil {
+		t.Fatal("Failed to edit recipe", err)
+	}
+
+	edited := &Recipe{DocxPath: docxPath}
+	err = edited.ParseFiles()
+	if err != nil {
+		t.Fatal("Failed to parse edited recipe", err)
+	}
+
+	if edited.Title != "Pancakes" {
+		t.Errorf("Title != \"Pancakes\": \"%s\"", edited.Title)
+	}
+
+	if strings.Join(edited.Info["ingredients"], "|") != "1 cup flour|2 eggs" {
+		t.Errorf("Unexpected ingredients %v", edited.Info["ingredients"])
+	}
+
+	if strings.Join(edited.Info["preparation"], "|") != "Fry in butter" {
+		t.Errorf("Unexpected preparation %v", edited.Info["preparation"])
+	}
+
+	if _, exists := edited.Info["tips"]; exists {
+		t.Errorf("Tips should be removed, got %v", edited.Info["tips"])
+	}
+
+	// the sidecar changed, so the version used for the first edit is stale
+	newVersion, err := edited.Version()
+	if err != nil {
+		t.Fatal("Failed to get version", err)
+	}
+
+	if newVersion == version {
+		t.Error("Version should change after an edit")
+	}
+
+	err = edited.Edit(version, "Waffles", nil)
+	if err != ErrEditConflict {
+		t.Error("Expected ErrEditConflict, got", err)
+	}
+
+	err = edited.Edit(newVersion, "  ", nil)
+	if err == nil {
+		t.Error("Expected an error for an empty title")
+	}
+}
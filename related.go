@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+// similarRecipeCount is how many similar recipes are shown on a recipe page
+const similarRecipeCount = 4
+
+// similarRecipes finds the recipes sharing the most ingredients and title words with rec
+func (h *Handler) similarRecipes(rec *recipe.Recipe, count int) ([]*recipe.Recipe, error) {
+	queries := []query.Query{}
+	for _, ingredient := range rec.Ingredients() {
+		ingredientQuery := bleve.NewMatchQuery(ingredient.Name)
+		ingredientQuery.SetField("ingredient_names")
+		ingredientQuery.SetOperator(query.MatchQueryOperatorAnd)
+		queries = append(queries, ingredientQuery)
+	}
+
+	titleQuery := bleve.NewMatchQuery(rec.Title)
+	titleQuery.SetField("title")
+	titleQuery.SetBoost(2)
+	queries = append(queries, titleQuery)
+
+	similarQuery := bleve.NewBooleanQuery()
+	similarQuery.AddMust(localizeQuery(bleve.NewDisjunctionQuery(queries...)))
+	similarQuery.AddMustNot(bleve.NewDocIDQuery([]string{rec.Title}))
+
+	searchResults, err := h.idx.Search(bleve.NewSearchRequestOptions(similarQuery, count, 0, false))
+	if err != nil {
+		return nil, err
+	}
+
+	similar := make([]*recipe.Recipe, 0, len(searchResults.Hits))
+	for _, hit := range searchResults.Hits {
+		if similarRecipe, exists := h.getRecipe(hit.ID); exists {
+			similar = append(similar, similarRecipe)
+		}
+	}
+
+	return similar, nil
+}
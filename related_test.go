@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+func TestSimilarRecipes(t *testing.T) {
+	handler := newTestHandler(t)
+	recipes := map[string][]string{
+		"Apple Crumble":     {"4 apples", "1 cup flour", "1/2 cup butter", "1/2 cup oats"},
+		"Apple Pie":         {"6 apples", "2 cups flour", "1 cup butter"},
+		"Apple Sauce":       {"6 apples", "1/4 cup sugar"},
+		"Oatmeal Cookies":   {"2 cups oats", "1 cup butter"},
+		"Cucumber Salad":    {"2 cucumbers", "1 tbsp vinegar"},
+		"Apple Cider Donut": {"1 cup apple cider", "2 cups flour"},
+	}
+
+	for title, ingredients := range recipes {
+		addTestRecipe(t, handler, &recipe.Recipe{
+			Title:    title,
+			Language: "en",
+			Info:     map[string][]string{"ingredients": ingredients},
+		})
+	}
+
+	rec, _ := handler.getRecipe("Apple Crumble")
+	for count := 1; count <= 6; count++ {
+		similar, err := handler.similarRecipes(rec, count)
+		if err != nil {
+			t.Fatal("Failed to find similar recipes", err)
+		}
+
+		if count <= 3 && len(similar) != count {
+			t.Errorf("Expected %d similar recipes, got %d", count, len(similar))
+		}
+
+		for _, similarRecipe := range similar {
+			if similarRecipe.Title == rec.Title {
+				t.Error("Expected Apple Crumble not to be similar to itself")
+			}
+
+			if similarRecipe.Title == "Cucumber Salad" {
+				t.Error("Expected Cucumber Salad not to be similar to Apple Crumble")
+			}
+		}
+
+		// sharing the most ingredients ranks first
+		if len(similar) > 0 && similar[0].Title != "Apple Pie" {
+			t.Errorf("Expected Apple Pie first, got %s", similar[0].Title)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search"
+	blevehtml "github.com/blevesearch/bleve/search/highlight/highlighter/html"
+	"github.com/blevesearch/bleve/search/query"
+)
+
+const (
+	searchPattern = "/search/"
+
+	// searchPageSize is how many results are on a page of /search/
+	searchPageSize = 20
+)
+
+// fieldAliases maps the friendly field names users type to indexed fields
+var fieldAliases = map[string]string{
+	"title":        "title",
+	"name":         "title",
+	"ingredient":   "ingredients",
+	"ingredients":  "ingredients",
+	"preparation":  "preparation",
+	"prep":         "preparation",
+	"instructions": "preparation",
+	"directions":   "preparation",
+	"tip":          "tips",
+	"tips":         "tips",
+	"tag":          "tags",
+	"tags":         "tags",
+	"serves":       "servings",
+	"servings":     "servings",
+	"oven":         "temperature",
+	"temp":         "temperature",
+	"temperature":  "temperature",
+	"minutes":      "total_minutes",
+	"time":         "total_minutes",
+	"author":       "author",
+	"by":           "author",
+	"category":     "category",
+	"folder":       "category",
+	"language":     "language",
+	"lang":         "language",
+}
+
+// languageNames are the names shown for recipe languages
+var languageNames = map[string]string{
+	"en": "English",
+	"de": "Deutsch",
+	"it": "Italiano",
+	"es": "Español",
+}
+
+// highlightFields are the stored fields that get highlighted, in display order
+var highlightFields = []string{
+	"title",
+	"ingredients",
+	"preparation",
+	"tips",
+	"serves",
+	"oven_temperature",
+}
+
+// highlightLabels are the names shown for highlighted fields
+var highlightLabels = map[string]string{
+	"title":            "title",
+	"ingredients":      "ingredients",
+	"preparation":      "preparation",
+	"tips":             "tips",
+	"serves":           "serves",
+	"oven_temperature": "oven temperature",
+}
+
+// searchURL creates the shareable URL for a page of search results
+// limited to recipes in language, if it is not empty
+func searchURL(search string, page int, language string) string {
+	values := url.Values{}
+	values.Set("q", search)
+	if page > 1 {
+		values.Set("page", strconv.Itoa(page))
+	}
+
+	if language != "" {
+		values.Set("lang", language)
+	}
+
+	return searchPattern + "?" + values.Encode()
+}
+
+// newHighlightedSearchRequest creates a request for a page of pageSize search results that marks matched terms
+// results are limited to recipes in language, if it is not empty, and counted by language
+func newHighlightedSearchRequest(searchQuery query.Query, page, pageSize int, language string) *bleve.SearchRequest {
+	if language != "" {
+		languageQuery := bleve.NewTermQuery(language)
+		languageQuery.SetField("language")
+		searchQuery = bleve.NewConjunctionQuery(searchQuery, languageQuery)
+	}
+
+	// the offset of pages past maxPage would overflow
+	if page > maxPage(pageSize) {
+		page = maxPage(pageSize)
+	}
+
+	request := bleve.NewSearchRequestOptions(searchQuery, pageSize, (page-1)*pageSize, false)
+	request.AddFacet("language", bleve.NewFacetRequest("language", len(languageAnalyzers)*2))
+	request.Highlight = bleve.NewHighlightWithStyle(blevehtml.Name)
+	for _, field := range highlightFields {
+		request.Highlight.AddField(field)
+	}
+
+	return request
+}
+
+// search runs a user's search for a page of pageSize results in language, if it is not empty
+// plain searches without any results, on any page, are tried again fuzzy
+func (h *Handler) search(searchText string, page, pageSize int, language string) (*bleve.SearchResult, error) {
+	searchQuery, err := parseSearch(searchText, false)
+	if err != nil {
+		return nil, err
+	}
+
+	searchResults, err := h.idx.Search(newHighlightedSearchRequest(searchQuery, page, pageSize, language))
+	if err != nil {
+		return nil, err
+	}
+
+	if searchResults.Total == 0 && !isFieldedSearch(searchText) {
+		return h.idx.Search(newHighlightedSearchRequest(newSearchQuery(
+			searchText,
+			true,
+		), page, pageSize, language))
+	}
+
+	return searchResults, nil
+}
+
+// templateFragments converts the highlighted fragments of a hit in display order
+// the title is left out since it is always shown
+func templateFragments(hit *search.DocumentMatch) (fragments []*TemplateFragment) {
+	for _, field := range highlightFields {
+		if field == "title" {
+			continue
+		}
+
+		for _, fragment := range hit.Fragments[field] {
+			// bleve gives the start of the field even when nothing matched
+			if !strings.Contains(fragment, "<mark>") {
+				continue
+			}
+
+			fragments = append(fragments, &TemplateFragment{
+				Field: highlightLabels[field],
+				// bleve escapes everything in the fragment but the matched
+				// terms, which are tokenized words
+				HTML: template.HTML(fragment),
+			})
+		}
+	}
+
+	return
+}
+
+// searchLanguageFacet converts the language facet of search results to a filter
+func searchLanguageFacet(searchText, language string, results search.FacetResults) *TemplateFacet {
+	result, exists := results["language"]
+	if !exists {
+		return nil
+	}
+
+	tmplFacet := &TemplateFacet{
+		Label: "Language",
+	}
+
+	if language != "" {
+		tmplFacet.ClearURL = searchURL(searchText, 1, "")
+	}
+
+	for _, term := range result.Terms {
+		label := languageNames[term.Term]
+		if label == "" {
+			label = term.Term
+		}
+
+		tmplFacet.Terms = append(tmplFacet.Terms, &TemplateFacetTerm{
+			Label:  label,
+			Count:  term.Count,
+			URL:    searchURL(searchText, 1, term.Term),
+			Active: term.Term == language,
+		})
+	}
+
+	// a single language is not much of a choice
+	if len(tmplFacet.Terms) < 2 && language == "" {
+		return nil
+	}
+
+	return tmplFacet
+}
+
+// SearchSyntaxError is a user facing error for malformed search queries
+type SearchSyntaxError struct {
+	Search string
+	Reason string
+}
+
+func (e *SearchSyntaxError) Error() string {
+	return fmt.Sprintf("Unable to understand the search \"%s\": %s", e.Search, e.Reason)
+}
+
+// fieldedSearchRegexp finds a term starting with + or -, or with a word and a colon
+// punctuation inside of words, like stir-fry or dinner?, is not query string syntax
+var fieldedSearchRegexp = regexp.MustCompile(`(?:^|\s)(?:[+-][^\s+-]|\(*([\p{L}_]+):)`)
+
+// isFieldedSearch determines if the search uses query string syntax
+// a word and a colon is only a field when the word is one of the field aliases, like "note: ..." is not
+func isFieldedSearch(search string) bool {
+	for _, match := range fieldedSearchRegexp.FindAllStringSubmatch(search, -1) {
+		if _, exists := fieldAliases[strings.ToLower(match[1])]; match[1] == "" || exists {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rewriteFieldAliases replaces friendly field names with indexed field names
+// other colons are escaped so they are searched as text, and text inside of quotes is left alone
+func rewriteFieldAliases(search string) (string, error) {
+	output := strings.Builder{}
+	runes := []rune(search)
+	inQuote := false
+	// tags are indexed lowercase, so the value of a tags term is lowercased
+	lowerValue := false
+
+	for i := 0; i < len(runes); i++ {
+		char := runes[i]
+		if char == '\\' && i+1 < len(runes) {
+			output.WriteRune(char)
+			if lowerValue {
+				output.WriteRune(unicode.ToLower(runes[i+1]))
+			} else {
+				output.WriteRune(runes[i+1])
+			}
+
+			i++
+			continue
+		}
+
+		if lowerValue && !inQuote && (unicode.IsSpace(char) || char == ')') {
+			lowerValue = false
+		}
+
+		if char == '"' {
+			inQuote = !inQuote
+		}
+
+		if lowerValue {
+			output.WriteRune(unicode.ToLower(char))
+			continue
+		}
+
+		// colons that do not follow a field name are text, like in a ratio of 1:2
+		if char == ':' && !inQuote {
+			output.WriteString(`\:`)
+			continue
+		}
+
+		// only the start of a term may be a field name
+		termStart := i == 0 || unicode.IsSpace(runes[i-1]) || strings.ContainsRune("+-(", runes[i-1])
+		if inQuote || !termStart || !unicode.IsLetter(char) {
+			output.WriteRune(char)
+			continue
+		}
+
+		end := i
+		for end < len(runes) && (unicode.IsLetter(runes[end]) || runes[end] == '_') {
+			end++
+		}
+
+		if end >= len(runes) || runes[end] != ':' {
+			output.WriteRune(char)
+			continue
+		}
+
+		field, exists := fieldAliases[strings.ToLower(string(runes[i:end]))]
+		if !exists {
+			output.WriteString(string(runes[i:end]))
+			i = end - 1
+			continue
+		}
+
+		output.WriteString(field + ":")
+		lowerValue = field == "tags"
+		i = end
+	}
+
+	if inQuote {
+		return "", fmt.Errorf("missing a closing quote")
+	}
+
+	return output.String(), nil
+}
+
+// parseSearch converts a user's search into a bleve query
+func parseSearch(search string, fuzzy bool) (query.Query, error) {
+	if !isFieldedSearch(search) {
+		return newSearchQuery(search, fuzzy), nil
+	}
+
+	rewritten, err := rewriteFieldAliases(search)
+	if err != nil {
+		return nil, &SearchSyntaxError{Search: search, Reason: err.Error()}
+	}
+
+	parsed, err := bleve.NewQueryStringQuery(rewritten).Parse()
+	if err != nil {
+		return nil, &SearchSyntaxError{Search: search, Reason: "check the quotes, colons and ranges"}
+	}
+
+	if validatable, ok := parsed.(query.ValidatableQuery); ok {
+		err = validatable.Validate()
+		if err != nil {
+			return nil, &SearchSyntaxError{Search: search, Reason: err.Error()}
+		}
+	}
+
+	return localizeQuery(parsed), nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+func TestIsFieldedSearch(t *testing.T) {
+	tests := map[string]bool{
+		"banana bread":               false,
+		"stir-fry":                   false,
+		"what's for dinner?":         false,
+		"salt + pepper":              false,
+		"10:30":                      false,
+		"note: less sugar":           false,
+		"colour:red":                 false,
+		"ingredient:butter":          true,
+		"cake -nuts":                 true,
+		"+chocolate cake":            true,
+		`title:"pound cake"`:         true,
+		"(tag:dessert OR tag:bread)": true,
+	}
+
+	for search, expected := range tests {
+		if fielded := isFieldedSearch(search); fielded != expected {
+			t.Errorf("isFieldedSearch(\"%s\") != %v", search, expected)
+		}
+	}
+}
+
+func TestRewriteFieldAliases(t *testing.T) {
+	tests := map[string]string{
+		"ingredient:butter -tips:nuts":   "ingredients:butter -tips:nuts",
+		`title:"pound cake" serves:>6`:   `title:"pound cake" servings:>6`,
+		"Name:Cake +by:Grandma":          "title:Cake +author:Grandma",
+		`"ingredient:butter" prep:bake`:  `"ingredient:butter" preparation:bake`,
+		`title:a\:b`:                     `title:a\:b`,
+		"tag:Dessert":                    "tags:dessert",
+		`-Tags:"Gluten Free" title:Cake`: `-tags:"gluten free" title:Cake`,
+		"(tag:Bread) Cake":               "(tags:bread) Cake",
+		`tag:A\B`:                        `tags:a\b`,
+		"colour:red":                     `colour\:red`,
+		"ratio 1:2 tag:Bread":            `ratio 1\:2 tags:bread`,
+		"Note: +salt":                    `Note\: +salt`,
+	}
+
+	for search, expected := range tests {
+		rewritten, err := rewriteFieldAliases(search)
+		if err != nil {
+			t.Errorf("Failed to rewrite \"%s\": %s", search, err)
+			continue
+		}
+
+		if rewritten != expected {
+			t.Errorf("rewriteFieldAliases(\"%s\") != \"%s\": \"%s\"", search, expected, rewritten)
+		}
+	}
+
+	if _, err := rewriteFieldAliases(`title:"pound cake`); err == nil {
+		t.Error("Expected an error rewriting an unclosed quote")
+	}
+}
+
+func TestParseSearch(t *testing.T) {
+	for _, search := range []string{"banana bread", "stir-fry", "ingredient:butter -tips:nuts", `title:"pound cake" serves:>6`, "colour:red", "ratio 1:2 tag:bread", "note: +salt"} {
+		if _, err := parseSearch(search, false); err != nil {
+			t.Errorf("Failed to parse \"%s\": %s", search, err)
+		}
+	}
+
+	for _, search := range []string{`title:"pound cake`, "title:", "serves:>"} {
+		_, err := parseSearch(search, false)
+		syntaxErr, ok := err.(*SearchSyntaxError)
+		if !ok {
+			t.Errorf("Expected a SearchSyntaxError for \"%s\", got %v", search, err)
+			continue
+		}
+
+		if syntaxErr.Search != search || syntaxErr.Reason == "" {
+			t.Errorf("Expected the search and a reason in the error, got %+v", syntaxErr)
+		}
+	}
+}
+
+func TestSearchTags(t *testing.T) {
+	handler := newTestHandler(t)
+
+	// tags are indexed lowercase however they are typed
+	for _, search := range []string{"tag:Bread", "tag:bread", "tags:BREAD"} {
+		results, err := handler.search(search, 1, 10, "")
+		if err != nil {
+			t.Errorf("Failed to search \"%s\": %s", search, err)
+			continue
+		}
+
+		if results.Total != 1 || results.Hits[0].ID != "Banana Bread" {
+			t.Errorf("Expected Banana Bread for \"%s\", got %d results", search, results.Total)
+		}
+	}
+}
+
+func TestTemplateFragmentsEscape(t *testing.T) {
+	handler := newTestHandler(t)
+	addTestRecipe(t, handler, &recipe.Recipe{
+		Title:    "Suspicious Salsa",
+		Language: "en",
+		Info: map[string][]string{
+			"ingredients": {`2 tomatoes <script>alert("salsa")</script>`, `<b onclick="x()">1 onion</b>`},
+		},
+	})
+
+	results, err := handler.search("tomatoes onion", 1, 10, "")
+	if err != nil {
+		t.Fatal("Failed to search", err)
+	}
+
+	if results.Total != 1 {
+		t.Fatalf("Expected 1 result, got %d", results.Total)
+	}
+
+	fragments := templateFragments(results.Hits[0])
+	if len(fragments) == 0 {
+		t.Fatal("Expected a highlighted fragment")
+	}
+
+	for _, fragment := range fragments {
+		// only the marks are left as HTML
+		html := strings.NewReplacer("<mark>", "", "</mark>", "").Replace(string(fragment.HTML))
+		if strings.ContainsAny(html, "<>") {
+			t.Errorf("Expected the recipe text to be escaped, got %s", fragment.HTML)
+		}
+
+		if !strings.Contains(string(fragment.HTML), "&lt;script&gt;") {
+			t.Errorf("Expected the script tag as text, got %s", fragment.HTML)
+		}
+
+		if !strings.Contains(string(fragment.HTML), "<mark>") {
+			t.Errorf("Expected the matched terms to be marked, got %s", fragment.HTML)
+		}
+	}
+}
+
+func TestSearchPagePastExactResults(t *testing.T) {
+	handler := newTestHandler(t)
+	templates, err := NewTemplate(nil)
+	if err != nil {
+		t.Fatal("Failed to parse templates", err)
+	}
+
+	handler.templates = templates
+	addTestRecipe(t, handler, &recipe.Recipe{Title: "Lemon Tart", Language: "en"})
+	addTestRecipe(t, handler, &recipe.Recipe{Title: "Lemon Dart", Language: "en"})
+
+	// only the second page is empty, so the search must not turn fuzzy
+	results, err := handler.search("tart", 2, 1, "")
+	if err != nil {
+		t.Fatal("Failed to search", err)
+	}
+
+	if results.Total != 1 || len(results.Hits) != 0 {
+		t.Errorf("Expected an empty page of 1 exact result, got %d hits of %d", len(results.Hits), results.Total)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.Search(recorder, httptest.NewRequest(http.MethodGet, searchURL("tart", 2, ""), nil))
+	if body := recorder.Body.String(); strings.Contains(body, "Lemon Dart") || !strings.Contains(body, "Unable to find recipes") {
+		t.Error("Expected no fuzzy results on page 2")
+	}
+
+	// a search without exact results is still tried fuzzy
+	results, err = handler.search("tant", 1, searchPageSize, "")
+	if err != nil {
+		t.Fatal("Failed to search", err)
+	}
+
+	if results.Total == 0 {
+		t.Error("Expected fuzzy results for tant")
+	}
+}
+
+func TestSearchHugePage(t *testing.T) {
+	handler := newTestHandler(t)
+	templates, err := NewTemplate(nil)
+	if err != nil {
+		t.Fatal("Failed to parse templates", err)
+	}
+
+	handler.templates = templates
+
+	results, err := handler.search("bread", math.MaxInt64, searchPageSize, "")
+	if err != nil {
+		t.Fatal("Failed to search", err)
+	}
+
+	if results.Total != 1 || len(results.Hits) != 0 {
+		t.Errorf("Expected an empty page of 1 result, got %d hits of %d", len(results.Hits), results.Total)
+	}
+
+	for _, page := range []string{"9223372036854775807", "922337203685477580"} {
+		recorder := httptest.NewRecorder()
+		handler.Search(recorder, httptest.NewRequest(http.MethodGet, "/search/?q=bread&page="+page, nil))
+		if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "Unable to find recipes") {
+			t.Errorf("Expected an empty page for page %s, got %d", page, recorder.Code)
+		}
+	}
+}
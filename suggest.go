@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search/query"
+)
+
+const (
+	apiSuggestPattern = "/api/suggest"
+
+	// suggestLimit is the default and max number of suggestions of each kind
+	suggestLimit = 8
+)
+
+// Suggestion is a completion for text typed into the search box
+type Suggestion struct {
+	// text to search for
+	Text string `json:"text"`
+	// either "recipe" or "ingredient"
+	Kind string `json:"kind"`
+	// relative URL to the recipe page, empty for ingredients
+	URL string `json:"url,omitempty"`
+}
+
+// suggestions finds recipe titles and ingredient names starting with the typed words
+func (h *Handler) suggestions(text string, limit int) ([]*Suggestion, error) {
+	suggestions := []*Suggestion{}
+	words := suggestWords(strings.ToLower(text))
+	if len(words) == 0 {
+		return suggestions, nil
+	}
+
+	prefixQuery := func(field string) query.Query {
+		matchQuery := bleve.NewMatchQuery(text)
+		matchQuery.SetField(field)
+		matchQuery.Analyzer = suggestQueryAnalyzer
+		matchQuery.SetOperator(query.MatchQueryOperatorAnd)
+		return matchQuery
+	}
+
+	titleResults, err := h.idx.Search(bleve.NewSearchRequestOptions(
+		prefixQuery("suggest_title"),
+		limit,
+		0,
+		false,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range titleResults.Hits {
+		suggestions = append(suggestions, &Suggestion{
+			Text: hit.ID,
+			Kind: "recipe",
+			URL:  recipePattern + url.PathEscape(hit.ID),
+		})
+	}
+
+	// the facet has every ingredient of the matching recipes,
+	// only keep the ones that actually start with the typed words
+	ingredientRequest := bleve.NewSearchRequestOptions(prefixQuery("suggest_ingredients"), 0, 0, false)
+	ingredientRequest.AddFacet("ingredients", bleve.NewFacetRequest("ingredient_keywords", limit*10))
+	ingredientResults, err := h.idx.Search(ingredientRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	ingredientFacet, exists := ingredientResults.Facets["ingredients"]
+	if !exists {
+		return suggestions, nil
+	}
+
+	ingredientCount := 0
+	for _, term := range ingredientFacet.Terms {
+		if ingredientCount >= limit {
+			break
+		}
+
+		if !hasWordPrefixes(term.Term, words) {
+			continue
+		}
+
+		ingredientCount++
+		suggestions = append(suggestions, &Suggestion{
+			Text: term.Term,
+			Kind: "ingredient",
+		})
+	}
+
+	return suggestions, nil
+}
+
+// suggestWords splits text into words like the unicode tokenizer, so "all-purpose" is two words
+func suggestWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// hasWordPrefixes determines if every prefix starts a word of text
+func hasWordPrefixes(text string, prefixes []string) bool {
+	words := suggestWords(text)
+	for _, prefix := range prefixes {
+		found := false
+		for _, word := range words {
+			if strings.HasPrefix(word, prefix) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// APISuggest handles search suggestions as JSON
+func (h *Handler) APISuggest(w http.ResponseWriter, r *http.Request) {
+	limit := suggestLimit
+	if rawLimit := r.FormValue("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 || limit > suggestLimit {
+			writeJSONError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(suggestLimit))
+			return
+		}
+	}
+
+	suggestions, err := h.suggestions(r.FormValue("q"), limit)
+	if err != nil {
+		h.logger.WithError(err).WithField("q", r.FormValue("q")).Errorln("Failed to get suggestions")
+		writeJSONError(w, http.StatusInternalServerError, "unable to get suggestions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, suggestions)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+// suggest gets the suggestions for the query parameters from /api/suggest
+func suggest(t *testing.T, handler *Handler, values url.Values) (int, []*Suggestion) {
+	recorder := httptest.NewRecorder()
+	handler.APISuggest(recorder, httptest.NewRequest(http.MethodGet, apiSuggestPattern+"?"+values.Encode(), nil))
+
+	suggestions := []*Suggestion{}
+	if recorder.Code == http.StatusOK {
+		err := json.Unmarshal(recorder.Body.Bytes(), &suggestions)
+		if err != nil {
+			t.Fatal("Failed to decode suggestions", err)
+		}
+	}
+
+	return recorder.Code, suggestions
+}
+
+func TestAPISuggest(t *testing.T) {
+	handler := newTestHandler(t)
+	for _, title := range []string{"Breaded Chicken Cutlets With Lemon", "Bread", "Sourdough Bread Loaf", "Cornbread"} {
+		addTestRecipe(t, handler, &recipe.Recipe{
+			Title:    title,
+			Language: "en",
+			Info: map[string][]string{
+				"ingredients": {"1 cup bread flour", "2 cups breadcrumbs", "1 tbsp all-purpose flour"},
+			},
+		})
+	}
+
+	status, suggestions := suggest(t, handler, url.Values{"q": {"Bre"}})
+	if status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+
+	recipes := []string{}
+	ingredients := []string{}
+	for _, suggestion := range suggestions {
+		if suggestion.Kind == "recipe" {
+			recipes = append(recipes, suggestion.Text)
+			if suggestion.URL != "/recipe/"+url.PathEscape(suggestion.Text) {
+				t.Errorf("Expected a link to %s, got %s", suggestion.Text, suggestion.URL)
+			}
+		} else {
+			ingredients = append(ingredients, suggestion.Text)
+		}
+	}
+
+	// only words starting with the text match, and shorter titles rank first
+	expected := "Bread, Banana Bread, Sourdough Bread Loaf, Breaded Chicken Cutlets With Lemon"
+	if strings.Join(recipes, ", ") != expected {
+		t.Errorf("Expected recipes %s, got %v", expected, recipes)
+	}
+
+	if strings.Join(ingredients, ", ") != "bread flour, breadcrumbs" {
+		t.Errorf("Expected the ingredients starting with bre, got %v", ingredients)
+	}
+
+	// words are split at punctuation as well as spaces
+	_, suggestions = suggest(t, handler, url.Values{"q": {"purp"}})
+	if len(suggestions) != 1 || suggestions[0].Text != "all-purpose flour" {
+		t.Errorf("Expected all-purpose flour for \"purp\", got %d suggestions", len(suggestions))
+	}
+
+	// every typed word must start a word
+	_, suggestions = suggest(t, handler, url.Values{"q": {"ban br"}})
+	if len(suggestions) != 1 || suggestions[0].Text != "Banana Bread" {
+		t.Errorf("Expected only Banana Bread for \"ban br\", got %d suggestions", len(suggestions))
+	}
+
+	_, suggestions = suggest(t, handler, url.Values{"q": {"bre"}, "limit": {"2"}})
+	kinds := map[string]int{}
+	for _, suggestion := range suggestions {
+		kinds[suggestion.Kind]++
+	}
+
+	if kinds["recipe"] != 2 || kinds["ingredient"] != 2 || suggestions[0].Text != "Bread" {
+		t.Errorf("Expected the best 2 of each kind, got %v", kinds)
+	}
+
+	for _, q := range []string{"", "   "} {
+		status, suggestions = suggest(t, handler, url.Values{"q": {q}})
+		if status != http.StatusOK || len(suggestions) != 0 {
+			t.Errorf("Expected no suggestions for \"%s\", got %d with status %d", q, len(suggestions), status)
+		}
+	}
+
+	for _, limit := range []string{"0", "9", "many"} {
+		if status, _ = suggest(t, handler, url.Values{"q": {"bre"}, "limit": {limit}}); status != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for limit %s, got %d", limit, status)
+		}
+	}
+}
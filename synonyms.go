@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/analysis/lang/en"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const (
+	// synonymFilterType is the registered bleve token filter type for synonyms
+	synonymFilterType = "recipe_card_synonyms"
+
+	// synonymFilterName is the synonym filter in the index mapping
+	synonymFilterName = "cooking_synonyms"
+
+	// cookingAnalyzer is english analysis with cooking synonyms
+	cookingAnalyzer = "cooking"
+
+	// defaultSynonyms are cooking synonym groups, the first of each group is
+	// the name every other one is also indexed as
+	defaultSynonyms = `# one group per line, separated by commas
+green onion, scallion, spring onion
+zucchini, courgette
+cilantro, coriander, coriander leaves
+eggplant, aubergine
+arugula, rocket
+bell pepper, capsicum, sweet pepper
+chickpea, garbanzo bean, garbanzo
+powdered sugar, confectioners sugar, icing sugar
+cornstarch, cornflour, corn starch
+all-purpose flour, plain flour, ap flour
+baking soda, bicarbonate of soda, bicarb
+heavy cream, double cream, heavy whipping cream, whipping cream
+half-and-half, half and half
+ground beef, minced beef, beef mince
+shrimp, prawn
+rutabaga, swede
+beet, beetroot
+romaine, cos lettuce
+snow pea, mangetout
+fava bean, broad bean
+jelly, jam, preserves
+skillet, frying pan, fry pan
+stovetop, hob
+molasses, treacle
+superfine sugar, caster sugar
+graham cracker, digestive biscuit
+semisweet chocolate, dark chocolate
+`
+)
+
+func init() {
+	registry.RegisterTokenFilter(synonymFilterType, synonymFilterConstructor)
+}
+
+// synonymFilter adds the canonical term of a synonym group alongside any
+// token or run of tokens that is a synonym
+type synonymFilter struct {
+	// analyzed synonym phrase to canonical term
+	canonical map[string][]byte
+	// most tokens in an analyzed synonym phrase
+	maxTokens int
+}
+
+// synonymFilterConstructor creates a synonymFilter from a list of synonym groups
+// phrases are analyzed the same as english text so plurals still match
+func synonymFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+	groups, ok := config["synonyms"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must specify synonyms")
+	}
+
+	analyzer, err := cache.AnalyzerNamed(en.AnalyzerName)
+	if err != nil {
+		return nil, err
+	}
+
+	analyze := func(phrase string) string {
+		terms := []string{}
+		for _, token := range analyzer.Analyze([]byte(phrase)) {
+			terms = append(terms, string(token.Term))
+		}
+
+		return strings.Join(terms, " ")
+	}
+
+	filter := &synonymFilter{
+		canonical: make(map[string][]byte),
+	}
+
+	for _, group := range groups {
+		groupString, ok := group.(string)
+		if !ok {
+			return nil, fmt.Errorf("synonym groups must be strings")
+		}
+
+		phrases := strings.Split(groupString, ",")
+		canonical := analyze(phrases[0])
+		if canonical == "" {
+			continue
+		}
+
+		for _, phrase := range phrases {
+			analyzed := analyze(phrase)
+			if analyzed == "" {
+				continue
+			}
+
+			filter.canonical[analyzed] = []byte(canonical)
+			if tokens := len(strings.Fields(analyzed)); tokens > filter.maxTokens {
+				filter.maxTokens = tokens
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+// Filter adds canonical synonym tokens at the position of the synonyms they replace
+func (f *synonymFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	output := make(analysis.TokenStream, 0, len(input))
+	for i, token := range input {
+		output = append(output, token)
+
+		phrase := ""
+		for n := 0; n < f.maxTokens && i+n < len(input); n++ {
+			if n > 0 {
+				phrase += " "
+			}
+
+			phrase += string(input[i+n].Term)
+			canonical, exists := f.canonical[phrase]
+			if !exists || (n == 0 && string(canonical) == phrase) {
+				continue
+			}
+
+			output = append(output, &analysis.Token{
+				Start:    token.Start,
+				End:      input[i+n].End,
+				Term:     canonical,
+				Position: token.Position,
+				Type:     token.Type,
+			})
+		}
+	}
+
+	return output
+}
+
+// ParseSynonyms reads comma separated synonym groups, one per line
+// blank lines and lines starting with # are ignored, every other line must have
+// at least two words or phrases
+func ParseSynonyms(reader io.Reader) (groups []string, err error) {
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		phrases := []string{}
+		for _, phrase := range strings.Split(line, ",") {
+			if phrase = strings.Join(strings.Fields(phrase), " "); phrase != "" {
+				phrases = append(phrases, phrase)
+			}
+		}
+
+		if len(phrases) < 2 {
+			return nil, fmt.Errorf("line %d: \"%s\" must be at least two synonyms separated by commas", lineNumber, line)
+		}
+
+		groups = append(groups, strings.Join(phrases, ", "))
+	}
+
+	return groups, scanner.Err()
+}
+
+// LoadSynonyms gets the default synonym groups extended with the ones in path
+func LoadSynonyms(path string) ([]string, error) {
+	groups, err := ParseSynonyms(strings.NewReader(defaultSynonyms))
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		return groups, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	extra, err := ParseSynonyms(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	return append(groups, extra...), nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve"
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+func TestParseSynonyms(t *testing.T) {
+	groups, err := ParseSynonyms(strings.NewReader(`# pantry staples
+
+passata,  tomato puree
+	# indented comments are ignored too
+scallion , green   onion,, spring onion
+`))
+	if err != nil {
+		t.Fatal("Failed to parse synonyms", err)
+	}
+
+	expected := []string{"passata, tomato puree", "scallion, green onion, spring onion"}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Expected %v, got %v", expected, groups)
+	}
+
+	for _, malformed := range []string{"passata", "passata,", " , ,"} {
+		_, err = ParseSynonyms(strings.NewReader("# comment\n" + malformed + "\n"))
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("Expected an error on line 2 for \"%s\", got %v", malformed, err)
+		}
+	}
+}
+
+func TestLoadSynonyms(t *testing.T) {
+	defaults, err := LoadSynonyms("")
+	if err != nil {
+		t.Fatal("Failed to load the default synonyms", err)
+	}
+
+	dir, err := ioutil.TempDir("", "recipe-card-synonyms")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "synonyms.txt")
+	err = ioutil.WriteFile(path, []byte("# extra\n\npassata, tomato puree\n"), 0644)
+	if err != nil {
+		t.Fatal("Failed to write synonyms", err)
+	}
+
+	groups, err := LoadSynonyms(path)
+	if err != nil {
+		t.Fatal("Failed to load synonyms", err)
+	}
+
+	if len(groups) != len(defaults)+1 || groups[len(groups)-1] != "passata, tomato puree" {
+		t.Errorf("Expected the defaults and passata, got %v", groups[len(defaults):])
+	}
+
+	err = ioutil.WriteFile(path, []byte("passata, tomato puree\npassata\n"), 0644)
+	if err != nil {
+		t.Fatal("Failed to write synonyms", err)
+	}
+
+	if _, err = LoadSynonyms(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Expected an error naming the file, got %v", err)
+	}
+
+	if _, err = LoadSynonyms(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestSynonymSearch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-synonyms")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "synonyms.txt")
+	err = ioutil.WriteFile(path, []byte("passata, tomato puree\n"), 0644)
+	if err != nil {
+		t.Fatal("Failed to write synonyms", err)
+	}
+
+	synonyms, err := LoadSynonyms(path)
+	if err != nil {
+		t.Fatal("Failed to load synonyms", err)
+	}
+
+	indexMapping, err := NewIndexMapping(synonyms)
+	if err != nil {
+		t.Fatal("Failed to create index mapping", err)
+	}
+
+	idx, err := bleve.NewMemOnly(indexMapping)
+	if err != nil {
+		t.Fatal("Failed to create index", err)
+	}
+
+	defer idx.Close()
+
+	// each pair must find the other in both directions
+	pairs := [][2]string{
+		{"scallions", "green onions"},
+		{"courgette", "zucchini"},
+		{"cilantro", "coriander"},
+		{"tomato", "tomatoes"},
+		{"passata", "tomato puree"},
+	}
+
+	for _, pair := range pairs {
+		for _, ingredient := range pair {
+			err = idx.Index(ingredient, newRecipeDocument(&recipe.Recipe{
+				Title:    "Recipe with " + ingredient,
+				Language: "en",
+				Info: map[string][]string{
+					"ingredients": {"1 cup " + ingredient + ", chopped"},
+				},
+			}))
+			if err != nil {
+				t.Fatal("Failed to index", ingredient, err)
+			}
+		}
+	}
+
+	for _, pair := range pairs {
+		for i, search := range pair {
+			other := pair[1-i]
+			results, err := idx.Search(bleve.NewSearchRequestOptions(newSearchQuery(search, false), 20, 0, false))
+			if err != nil {
+				t.Fatal("Failed to search", search, err)
+			}
+
+			found := false
+			for _, hit := range results.Hits {
+				found = found || hit.ID == other
+			}
+
+			if !found {
+				t.Errorf("Expected \"%s\" to find the recipe with %s", search, other)
+			}
+		}
+	}
+}
@@ -63,6 +63,40 @@ img {
 }
 .recipeCardImageSection {
 	text-align: center;
+}
+.searchHelp {
+	max-width: 600px;
+}
+.facetTerm {
+	display: block;
+	color: unset;
+	text-decoration: none;
+}
+.facetTerm.inline {
+	display: inline;
+	margin-right: 8px;
+}
+.facetTerm.active {
+	font-weight: bold;
+}
+.recipeFragment mark {
+	padding: 0 2px;
+}
+.searchHelpToggle {
+	cursor: pointer;
+	font-size: 0.8em;
+	text-align: right;
+}
+.editForm label {
+	text-transform: capitalize;
+}
+.scanUpload {
+	margin-top: 2rem;
+}
+.duplicateScan {
+	width: 200px;
+	margin: 0 8px 8px 0;
+	text-align: center;
 }`
 
 	templateHeader = `{{ define "header" }}
@@ -79,6 +113,10 @@ img {
 	<header class="sticky">
 		<a href="/" class="logo">Recipe Card</a>
 		<a role="button" href="/recipes">Recipes</a>
+		<a role="button" href="/pantry/">What can I make?</a>
+		<a role="button" href="/recipes/new">New recipe</a>
+		<a role="button" href="/duplicates/">Duplicate scans</a>
+		<a role="button" href="/cookbook/">Cookbook</a>
 	</header>
 {{ end }}`
 
@@ -91,12 +129,68 @@ img {
 <div class="row cols-sm-4">
 	<div>
 	</div>
-	<form action="/search/" method="post">
+	<form action="/search/" method="get">
 		<div class="input-group vertical">
-			<input type="text" value="{{ .SearchValue }}" name="search" id="search" placeholder="search">
+			<input type="text" value="{{ .SearchValue }}" name="q" id="search" placeholder="search" list="search-suggestions" autocomplete="off">
+			<datalist id="search-suggestions"></datalist>
+			<label for="search-help" class="searchHelpToggle">search help</label>
 		</div>
 	</form>
 </div>
+<script>
+(function() {
+	var input = document.getElementById("search");
+	var list = document.getElementById("search-suggestions");
+	var timer = null;
+
+	input.addEventListener("input", function() {
+		clearTimeout(timer);
+		timer = setTimeout(function() {
+			var text = input.value.trim();
+			if (text === "") {
+				list.innerHTML = "";
+				return;
+			}
+
+			fetch("/api/suggest?q=" + encodeURIComponent(text)).then(function(response) {
+				return response.ok ? response.json() : [];
+			}).then(function(suggestions) {
+				list.innerHTML = "";
+				suggestions.forEach(function(suggestion) {
+					var option = document.createElement("option");
+					option.value = suggestion.text;
+					option.label = suggestion.kind;
+					list.appendChild(option);
+				});
+			}).catch(function() {});
+		}, 150);
+	});
+})();
+</script>
+<input id="search-help" type="checkbox">
+<div class="modal">
+	<div class="card searchHelp">
+		<label for="search-help" class="close"></label>
+		<h3 class="section">Searching</h3>
+		<div class="section">
+			<p>Words search every part of a recipe, titles count the most.</p>
+			<table>
+				<tbody>
+					<tr><td><code>ingredient:butter</code></td><td>butter is an ingredient</td></tr>
+					<tr><td><code>title:"pound cake"</code></td><td>the exact phrase is in the title</td></tr>
+					<tr><td><code>-tips:nuts</code></td><td>the tips do not mention nuts</td></tr>
+					<tr><td><code>+tag:dessert</code></td><td>must be tagged dessert</td></tr>
+					<tr><td><code>serves:>6</code></td><td>serves more than 6, also <code>>=</code>, <code><</code> and <code><=</code></td></tr>
+					<tr><td><code>oven:<=350</code></td><td>bakes at or below 350°F</td></tr>
+					<tr><td><code>time:<60</code></td><td>takes under an hour</td></tr>
+					<tr><td><code>choc*</code></td><td>words starting with choc</td></tr>
+					<tr><td><code>buter~</code></td><td>words spelled almost like buter</td></tr>
+				</tbody>
+			</table>
+			<p>Fields: title, ingredient, prep, tips, tag, serves, oven, time, author, category, lang</p>
+		</div>
+	</div>
+</div>
 {{ end }}`
 
 	templateIndex = `{{ define "index" }}
@@ -110,15 +204,21 @@ img {
 	{{ if .StockImage }}
 		<div class="section recipeCardImageSection">
 			<a href="{{ .URL }}">
-				<img class="media shadowed rounded" alt="Recipe Image" src="{{ index .StockImage }}">
+				<img class="media shadowed rounded" alt="Recipe Image" src="{{ .StockImage }}?w=400" srcset="{{ .StockImageSrcset }}" sizes="(max-width: 767px) 100vw, 33vw">
 			</a>
 		</div>
 	{{ end }}
 	<div class="section">
-	<a href="{{ .URL }}" class="recipeCardTitle"><h2>{{ .ID }}</h2></a>
+	<a href="{{ .URL }}" class="recipeCardTitle"><h2>{{ .ID }}{{ if .Score }} <small>score {{ printf "%.3f" .Score }}</small>{{ end }}</h2></a>
 	</div>
 	<div class="section recipeCardDesc">
+	{{ if .Fragments }}
+		{{ range .Fragments }}
+		<p class="recipeFragment"><small>{{ .Field }}</small> …{{ .HTML }}…</p>
+		{{ end }}
+	{{ else }}
 	{{ .Description }}
+	{{ end }}
 	</div>
 </div>
 {{ end }}`
@@ -134,8 +234,23 @@ img {
 	templateSearch = `{{ define "search" }}
 {{ template "header" . }}
 {{ template "searchform" . }}
-{{ if .Recipes }}
+{{ if .SearchError }}
+<div class="card error fluid">
+	<p class="section">{{ .SearchError }}</p>
+</div>
+{{ else if .Recipes }}
+<p>{{ .Total }} results, <a href="{{ .SearchURL }}">link to these results</a></p>
+{{ range .Facets }}
+<p>
+	{{ .Label }}:
+	{{ range .Terms }}
+	<a href="{{ .URL }}" class="facetTerm inline{{ if .Active }} active{{ end }}">{{ .Label }} <mark class="tag">{{ .Count }}</mark></a>
+	{{ end }}
+	{{ if .ClearURL }}<a href="{{ .ClearURL }}"><small>clear</small></a>{{ end }}
+</p>
+{{ end }}
 {{ template "recipecards" .Recipes }}
+{{ template "pagination" . }}
 {{ else }}
 <h4>Unable to find recipes. :(</h4>
 {{ end }}
@@ -144,34 +259,389 @@ img {
 
 	templateRecipes = `{{ define "recipes" }}
 {{ template "header" . }}
-{{ if .Recipes }}
-{{ template "recipecards" .Recipes }}
-{{ else }}
-<h1>No recipes found :(</h2>
+<div class="row">
+	<div class="col-sm-12 col-md-3">
+		{{ range .Facets }}
+		<div class="card fluid facet">
+			<h4 class="section">{{ .Label }} {{ if .ClearURL }}<a href="{{ .ClearURL }}"><small>clear</small></a>{{ end }}</h4>
+			<div class="section">
+			{{ range .Terms }}
+				<a href="{{ .URL }}" class="facetTerm{{ if .Active }} active{{ end }}">{{ .Label }} <mark class="tag">{{ .Count }}</mark></a>
+			{{ end }}
+			</div>
+		</div>
+		{{ end }}
+	</div>
+	<div class="col-sm-12 col-md-9">
+		<div class="button-group">
+		{{ range .SortOptions }}
+			<a role="button" href="{{ .URL }}"{{ if .Active }} class="primary"{{ end }}>{{ .Label }}</a>
+		{{ end }}
+		</div>
+		{{ if .SearchError }}
+		<div class="card error fluid">
+			<p class="section">{{ .SearchError }}</p>
+		</div>
+		{{ else if .Recipes }}
+		<p>{{ .Total }} recipes</p>
+		{{ template "recipecards" .Recipes }}
+		{{ else }}
+		<h1>No recipes found :(</h1>
+		{{ end }}
+		{{ template "pagination" . }}
+	</div>
+</div>
+{{ template "footer" . }}
+{{ end }}`
+
+	templatePagination = `{{ define "pagination" }}
+{{ if or .PrevURL .NextURL }}
+<div class="button-group pagination">
+	{{ if .PrevURL }}<a role="button" href="{{ .PrevURL }}">Previous</a>{{ end }}
+	{{ if .NextURL }}<a role="button" href="{{ .NextURL }}">Next</a>{{ end }}
+</div>
+{{ end }}
+{{ end }}`
+
+	templateEdit = `{{ define "edit" }}
+{{ template "header" . }}
+{{ with .Edit }}
+<div class="container">
+	{{ if .Error }}
+	<div class="card error fluid">
+		<p class="section">{{ .Error }}</p>
+	</div>
+	{{ end }}
+	<form action="{{ .Action }}" method="post" class="editForm"{{ if .New }} enctype="multipart/form-data"{{ end }}>
+		{{ if not .New }}
+		<input type="hidden" name="version" value="{{ .Version }}">
+		{{ end }}
+		<div class="input-group vertical">
+			<label for="title">Title</label>
+			<input type="text" name="title" id="title" value="{{ .Title }}" required>
+			{{ if .New }}
+			<label for="category">Category, folders separated by /</label>
+			<input type="text" name="category" id="category" value="{{ .Category }}" list="categories">
+			<datalist id="categories">
+				{{ range .Categories }}<option value="{{ . }}">{{ end }}
+			</datalist>
+			<label for="author">Author</label>
+			<input type="text" name="author" id="author" value="{{ .Author }}">
+			<label for="tags">Tags, separated by commas</label>
+			<input type="text" name="tags" id="tags" value="{{ .Tags }}">
+			<label for="language">Language</label>
+			<select name="language" id="language">
+				<option value="">Detect from the text</option>
+				{{ range .Languages }}
+				<option value="{{ .Value }}"{{ if .Selected }} selected{{ end }}>{{ .Label }}</option>
+				{{ end }}
+			</select>
+			<label for="image">Stock image, a JPEG</label>
+			<input type="file" name="image" id="image" accept="image/jpeg">
+			{{ end }}
+			{{ range $index, $section := .Sections }}
+			<label for="section-{{ $index }}">{{ .Name }}, one per line</label>
+			<textarea name="{{ .Name }}" id="section-{{ $index }}" rows="6">{{ .Text }}</textarea>
+			{{ end }}
+		</div>
+		<div class="button-group">
+			<input type="submit" class="primary" value="{{ if .New }}Create{{ else }}Save{{ end }}">
+			<a role="button" href="{{ .CancelURL }}">Cancel</a>
+		</div>
+	</form>
+	{{ if .New }}
+	<p>Saved a recipe from a website? <a href="/recipes/import">Import the web page</a> instead.</p>
+	{{ end }}
+</div>
 {{ end }}
 {{ template "footer" . }}
+{{ end }}`
+
+	templateDuplicates = `{{ define "duplicates" }}
+{{ template "header" . }}
+<div class="container">
+	<h2>Duplicate scans <small>Photos that look like the same recipe card</small></h2>
+	{{ range .DuplicateGroups }}
+	<div class="card fluid">
+		<div class="section">
+			<h4>{{ len .Scans }} similar scans in
+			{{ range $index, $recipe := .Recipes }}{{ if $index }}, {{ end }}<a href="{{ $recipe.URL }}">{{ $recipe.ID }}</a>{{ end }}
+			</h4>
+		</div>
+		<div class="section row">
+		{{ range .Scans }}
+			<figure class="duplicateScan">
+				<a href="{{ .Image.URL }}"><img class="shadowed rounded" src="{{ .Image.Thumbnail }}" srcset="{{ .Image.Srcset }}" sizes="200px" alt="Recipe card"></a>
+				<figcaption><a href="{{ .Recipe.URL }}">{{ .Recipe.ID }}</a><br><small>{{ .Name }}</small></figcaption>
+			</figure>
+		{{ end }}
+		</div>
+	</div>
+	{{ else }}
+	<h4>No duplicate scans found.</h4>
+	{{ end }}
+</div>
+{{ template "footer" . }}
+{{ end }}`
+
+	templateImport = `{{ define "import" }}
+{{ template "header" . }}
+{{ with .Import }}
+<div class="container">
+	<h2>Import recipe <small>From a web page saved with schema.org recipe data</small></h2>
+	{{ if .Error }}
+	<div class="card error fluid">
+		<p class="section">{{ .Error }}</p>
+	</div>
+	{{ end }}
+	<form action="/recipes/import" method="post" class="editForm" enctype="multipart/form-data">
+		<div class="input-group vertical">
+			<label for="page">Saved web page</label>
+			<input type="file" name="page" id="page" accept=".html,.htm,text/html" required>
+			<label for="category">Category, folders separated by /</label>
+			<input type="text" name="category" id="category" value="{{ .Category }}" list="categories">
+			<datalist id="categories">
+				{{ range .Categories }}<option value="{{ . }}">{{ end }}
+			</datalist>
+			<label for="image">Stock image, the page's own image is not downloaded</label>
+			<input type="file" name="image" id="image" accept="image/jpeg,image/png,image/gif,image/webp,image/tiff">
+		</div>
+		<div class="button-group">
+			<input type="submit" class="primary" value="Import">
+			<a role="button" href="/recipes/">Cancel</a>
+		</div>
+	</form>
+</div>
+{{ end }}
+{{ template "footer" . }}
+{{ end }}`
+
+	templateCookbook = `{{ define "cookbook" }}
+{{ template "header" . }}
+{{ with .Cookbook }}
+<div class="container">
+	<h2>Cookbook <small>Compile recipes into a book with contents and an index of ingredients</small></h2>
+	{{ if .Error }}
+	<div class="card error fluid">
+		<p class="section">{{ .Error }}</p>
+	</div>
+	{{ end }}
+	<form action="/cookbook/" method="get" class="editForm">
+		<div class="input-group vertical">
+			<label for="title">Title</label>
+			<input type="text" name="title" id="title" value="{{ .Title }}" required>
+			<label for="category">Category, including the folders inside it</label>
+			<select name="category" id="category">
+				<option value="">Every category</option>
+				{{ range .Categories }}
+				<option value="{{ .Value }}"{{ if .Selected }} selected{{ end }}>{{ .Label }}</option>
+				{{ end }}
+			</select>
+			<label for="tag">Tag</label>
+			<input type="text" name="tag" id="tag" value="{{ .Tag }}" list="tags">
+			<datalist id="tags">
+				{{ range .Tags }}<option value="{{ . }}">{{ end }}
+			</datalist>
+			<label for="q">Search</label>
+			<input type="search" name="q" id="q" value="{{ .Query }}" placeholder="Every recipe">
+			<label for="format">Format</label>
+			<select name="format" id="format">
+				{{ range .Formats }}
+				<option value="{{ .Value }}"{{ if .Selected }} selected{{ end }}>{{ .Label }}</option>
+				{{ end }}
+			</select>
+		</div>
+		<div class="button-group">
+			<input type="submit" class="primary" value="Compile">
+		</div>
+	</form>
+</div>
+{{ end }}
+{{ template "footer" . }}
+{{ end }}`
+
+	templatePrint = `{{ define "print" }}
+<!doctype html>
+<html lang="en">
+	<head>
+		<title>{{ .PageTitle }}</title>
+		<meta charset="utf-8">
+		<meta name="viewport" content="width=device-width, initial-scale=1">
+		<link rel="stylesheet" href="/css/mini.css">
+		<style>
+		@page {
+			size: {{ .Print.Width }}in {{ .Print.Height }}in;
+			margin: 0;
+		}
+		.printCard {
+			box-sizing: border-box;
+			width: {{ .Print.Width }}in;
+			min-height: {{ .Print.Height }}in;
+			margin: 1rem auto;
+			padding: 0.3in;
+			border: 1px solid #ccc;
+			background: white;
+			font-family: Georgia, serif;
+			font-size: 10pt;
+			line-height: 1.3;
+		}
+		.printCard h1 {
+			margin: 0 0 0.1in;
+			padding: 0;
+			font-size: 16pt;
+			border-bottom: 1px solid #c33;
+		}
+		.printCard h2 {
+			margin: 0.1in 0 0;
+			padding: 0;
+			font-size: 11pt;
+			text-transform: capitalize;
+		}
+		.printCard p {
+			margin: 0;
+			padding: 0;
+		}
+		.printCard img {
+			float: right;
+			max-width: 35%;
+			max-height: 1.6in;
+			margin: 0 0 0.1in 0.1in;
+		}
+		.printSection {
+			break-inside: avoid;
+		}
+		@media print {
+			.printOptions {
+				display: none;
+			}
+			body, .printCard {
+				margin: 0;
+				border: none;
+				background: white;
+			}
+		}
+		</style>
+	</head>
+	<body>
+	{{ with .Print }}
+	<div class="container printOptions">
+		<form action="{{ .URL }}" method="get">
+			<fieldset>
+				<legend><a href="{{ .RecipeURL }}">{{ .Title }}</a></legend>
+				<label for="size">Card</label>
+				<select name="size" id="size">
+				{{ range .Sizes }}
+					<option value="{{ .Value }}"{{ if .Selected }} selected{{ end }}>{{ .Label }}</option>
+				{{ end }}
+				</select>
+				<label for="servings">Servings</label>
+				<input type="number" name="servings" id="servings" min="1" step="any" value="{{ .Servings }}">
+				<input type="checkbox" name="image" id="image" value="1"{{ if .Image }} checked{{ end }}>
+				<label for="image">Stock image</label>
+				<input type="submit" value="Update">
+				<button type="button" class="primary" onclick="window.print()">Print</button>
+			</fieldset>
+		</form>
+		{{ if .ScaleError }}
+		<div class="card error fluid">
+			<p class="section">{{ .ScaleError }}</p>
+		</div>
+		{{ end }}
+	</div>
+	<div class="printCard">
+		{{ if .StockImage }}
+		<img src="{{ .StockImage }}?w=400" alt="Stock image">
+		{{ end }}
+		<h1>{{ .Title }}</h1>
+		{{ range .Sections }}
+		<div class="printSection">
+			<h2>{{ .Name }}</h2>
+			{{ range .Lines }}
+			<p>{{ . }}</p>
+			{{ end }}
+		</div>
+		{{ end }}
+	</div>
+	{{ end }}
+	</body>
+</html>
+{{ end }}`
+
+	templatePantry = `{{ define "pantry" }}
+{{ template "header" . }}
+<div class="container">
+	<form action="/pantry/" method="get">
+		<div class="input-group vertical">
+			<label for="ingredients">Ingredients on hand, one per line or separated by commas</label>
+			<textarea name="ingredients" id="ingredients" rows="4" placeholder="butter, eggs, flour">{{ .PantryValue }}</textarea>
+			<input type="submit" class="primary" value="What can I make?">
+		</div>
+	</form>
+	{{ if .SearchError }}
+	<div class="card error fluid">
+		<p class="section">{{ .SearchError }}</p>
+	</div>
+	{{ else if .PantryMatches }}
+	<div class="row">
+		{{ range .PantryMatches }}
+		<div class="card">
+			<div class="section">
+				<a href="{{ .Recipe.URL }}" class="recipeCardTitle"><h3>{{ .Recipe.ID }} <small>{{ .Percent }}% on hand</small></h3></a>
+			</div>
+			<div class="section recipeCardDesc">
+				<p>Have:</p>
+				<ul>
+				{{ range .Have }}
+					<li>{{ . }}</li>
+				{{ end }}
+				</ul>
+				{{ if .Missing }}
+				<p>Missing:</p>
+				<ul>
+				{{ range .Missing }}
+					<li>{{ . }}</li>
+				{{ end }}
+				</ul>
+				{{ end }}
+			</div>
+		</div>
+		{{ end }}
+	</div>
+	{{ else if .PantryValue }}
+	<h4>Unable to find recipes with those ingredients. :(</h4>
+	{{ end }}
+</div>
+{{ template "footer" . }}
 {{ end }}`
 
 	templateRecipe = `{{ define "recipe" }}
 {{ template "header" . }}
+{{ if .UploadError }}
+<div class="container">
+	<div class="card error fluid">
+		<p class="section">{{ .UploadError }}</p>
+	</div>
+</div>
+{{ end }}
 {{ with index .Recipes 0 }}
+{{ if .JSONLD }}<script type="application/ld+json">{{ .JSONLD }}</script>{{ end }}
 <div class="container">
 	<div class="row">
 	{{ if or .StockImage .Images }}
 		<div id="recipeImages" class="col-sm-3">
 	{{ end }}
 	{{ if .StockImage }}
-		<img class="shadowed rounded" src="{{ .StockImage }}" alt="Stock image">
+		<img class="shadowed rounded" src="{{ .StockImage }}?w=400" srcset="{{ .StockImageSrcset }}" sizes="(max-width: 767px) 100vw, 25vw" alt="Stock image">
 	{{ end }}
 	{{ range $index, $results := .Images }}
 		<label for="modal-{{ $index }}">
-		<img class="recipeImage shadowed rounded" src="{{ . }}" alt="Recipe card">
+		<img class="recipeImage shadowed rounded" src="{{ .Thumbnail }}" srcset="{{ .Srcset }}" sizes="(max-width: 767px) 100vw, 25vw" alt="Recipe card">
 		</label>
 		<input id="modal-{{ $index }}" type="checkbox">
 		<div class="modal">
 			<div class="container imageModal">
 				<label for="modal-{{ $index }}" class="close"></label>
-				<img src="{{ . }}" alt="Recipe card">
+				<img src="{{ .URL }}" alt="Recipe card">
 			</div>
 		</div>
 	{{ end }}
@@ -180,12 +650,48 @@ img {
 	{{ end }}
 		<div class="col-sm">
 		<a class="recipeCardTitle" href="{{ .DocxURL }}"><h1>{{ .ID }}</h1></a>
+		<a class="button small" href="{{ .EditURL }}">Edit</a>
+		<a class="button small" href="{{ .PrintURL }}">Print</a>
+		<a class="button small" href="{{ .PDFURL }}">PDF</a>
+		{{ if .Images }}<a class="button small" href="{{ .PDFURL }}?scans=1">PDF with scans</a>{{ end }}
 		<hr>
 		{{ .Description }}
+		{{ if .Attachments }}
+		<h3>Attachments</h3>
+		<ul class="attachments">
+		{{ range .Attachments }}
+			<li><a href="{{ .URL }}" download>{{ .Name }}</a>{{ if eq .Pages 1 }} (1 page){{ else if .Pages }} ({{ .Pages }} pages){{ end }}</li>
+		{{ end }}
+		</ul>
+		{{ end }}
+		<form action="{{ .ScansURL }}" method="post" enctype="multipart/form-data" class="scanUpload">
+			<label for="scans">Add photos of the recipe card</label>
+			<input type="file" name="scans" id="scans" accept="image/jpeg,image/png,image/gif,image/webp,image/tiff" capture="environment" multiple required>
+			<input type="submit" value="Upload">
+		</form>
 		</div>
 	</div>
 </div>
 {{ end }}
+{{ if .SimilarRecipes }}
+<div class="container">
+	<h3>Similar recipes</h3>
+	<div class="row">
+	{{ range .SimilarRecipes }}
+		<div class="card small">
+			{{ if .StockImage }}
+			<a href="{{ .URL }}" class="section recipeCardImageSection">
+				<img class="media" alt="Recipe Image" src="{{ .StockImage }}?w=200" srcset="{{ .StockImageSrcset }}" sizes="200px">
+			</a>
+			{{ end }}
+			<div class="section">
+				<a href="{{ .URL }}" class="recipeCardTitle"><h4>{{ .ID }}</h4></a>
+			</div>
+		</div>
+	{{ end }}
+	</div>
+</div>
+{{ end }}
 {{ template "footer" . }}
 {{ end }}`
 )
@@ -196,8 +702,181 @@ type TemplateData struct {
 	PageTitle string
 	// previous value used for searching
 	SearchValue string
+	// user facing reason the search failed
+	SearchError string
+	// shareable relative URL of the current search results
+	SearchURL string
 	// list of recipes to display
 	Recipes []*TemplateRecipe
+	// previous value used for the pantry search
+	PantryValue string
+	// recipes like the one being shown
+	SimilarRecipes []*TemplateRecipe
+	// recipes that can be made with the pantry items
+	PantryMatches []*TemplatePantryMatch
+	// filters for the recipes with their counts
+	Facets []*TemplateFacet
+	// ways to order the recipes
+	SortOptions []*TemplateLink
+	// total number of recipes over every page
+	Total uint64
+	// relative URL to the previous page, empty on the first page
+	PrevURL string
+	// relative URL to the next page, empty on the last page
+	NextURL string
+	// recipe being edited
+	Edit *TemplateEdit
+	// user facing reason uploading scans failed
+	UploadError string
+	// scans that look alike
+	DuplicateGroups []*TemplateDuplicateGroup
+	// recipe being printed
+	Print *TemplatePrint
+	// cookbook being compiled
+	Cookbook *TemplateCookbook
+	// web page being imported
+	Import *TemplateImport
+}
+
+// TemplateImport is the form that imports a recipe from a saved web page
+type TemplateImport struct {
+	Category string
+	// user facing reason the page was not imported
+	Error string
+	// existing categories to choose from
+	Categories []string
+}
+
+// TemplateCookbook is the form that chooses the recipes of a cookbook
+type TemplateCookbook struct {
+	Title    string
+	Category string
+	Tag      string
+	Query    string
+	// user facing reason the cookbook could not be compiled
+	Error      string
+	Categories []*TemplateOption
+	// existing tags to choose from
+	Tags    []string
+	Formats []*TemplateOption
+}
+
+// TemplatePrint is a recipe laid out on an index card
+type TemplatePrint struct {
+	// relative URL the print options are sent to
+	URL string
+	// relative URL to the recipe page
+	RecipeURL string
+	Title     string
+	// size of the card in inches as it is held
+	Width  int
+	Height int
+	// index cards to choose from
+	Sizes []*TemplateOption
+	// whether the stock image was asked for
+	Image bool
+	// relative URL to the stock image, empty when it is not shown
+	StockImage string
+	// servings the ingredients are scaled to, empty when they are not
+	Servings string
+	// user facing reason the recipe could not be scaled
+	ScaleError string
+	// recipe categories in order
+	Sections []*TemplatePrintSection
+}
+
+// TemplatePrintSection is a recipe category with its lines
+type TemplatePrintSection struct {
+	Name  string
+	Lines []string
+}
+
+// TemplateEdit is the form for editing a recipe
+type TemplateEdit struct {
+	// relative URL the form is posted to
+	Action string
+	// relative URL to leave without saving
+	CancelURL string
+	// version of the recipe files the form was filled from
+	Version string
+	Title   string
+	// lines of each recipe category in order
+	Sections []*TemplateEditSection
+	// user facing reason the edit was not saved
+	Error string
+	// the form creates a recipe, which also has the fields below
+	New      bool
+	Category string
+	Author   string
+	// comma separated tags
+	Tags     string
+	Language string
+	// existing categories to choose from
+	Categories []string
+	Languages  []*TemplateOption
+}
+
+// TemplateOption is a choice in a select input
+type TemplateOption struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+// TemplateEditSection is a recipe category with its lines
+type TemplateEditSection struct {
+	Name string
+	// one line per line of the category
+	Text string
+}
+
+// TemplateFacet is a filter with the values that can be chosen
+type TemplateFacet struct {
+	Label string
+	// relative URL that removes this filter, empty if it is not used
+	ClearURL string
+	Terms    []*TemplateFacetTerm
+}
+
+// TemplateFacetTerm is a value of a filter with how many recipes have it
+type TemplateFacetTerm struct {
+	Label  string
+	Count  int
+	URL    string
+	Active bool
+}
+
+// TemplateLink is a link that may be the current choice
+type TemplateLink struct {
+	Label  string
+	URL    string
+	Active bool
+}
+
+// TemplatePantryMatch is a recipe with the ingredients that are and are not on hand
+type TemplatePantryMatch struct {
+	Recipe *TemplateRecipe
+	// ingredient lines that are on hand
+	Have []string
+	// ingredient lines that are not on hand
+	Missing []string
+	// percent of the ingredients that are on hand
+	Percent int
+}
+
+// TemplateDuplicateGroup is a set of scans that look like the same recipe card
+type TemplateDuplicateGroup struct {
+	// recipes the scans are in
+	Recipes []*TemplateRecipe
+	Scans   []*TemplateDuplicateScan
+}
+
+// TemplateDuplicateScan is a scan in a TemplateDuplicateGroup
+type TemplateDuplicateScan struct {
+	// file name of the scan
+	Name   string
+	Recipe *TemplateRecipe
+	Image  *TemplateImage
 }
 
 // TemplateRecipe used for all recipes whether it is an aggregate or a singular recipe
@@ -210,10 +889,55 @@ type TemplateRecipe struct {
 	URL string
 	// relative URL to the recipe docx file
 	DocxURL string
+	// relative URL to the recipe edit form
+	EditURL string
+	// relative URL scans of the recipe card are uploaded to
+	ScansURL string
+	// relative URL to the printable index card
+	PrintURL string
+	// relative URL to the recipe as a PDF
+	PDFURL string
 	// stock image relative path for the recipe
 	StockImage string
-	// card scan images relative paths for the recipe
-	Images []string
+	// sizes of the stock image
+	StockImageSrcset template.Srcset
+	// card scan images for the recipe
+	Images []*TemplateImage
+	// documents in the recipe's folder
+	Attachments []*TemplateAttachment
+	// schema.org Recipe JSON-LD, only on the recipe page
+	JSONLD template.JS
+	// search relevance, 0 when not searching
+	Score float64
+	// highlighted parts of the recipe that matched the search
+	Fragments []*TemplateFragment
+}
+
+// TemplateImage is an image with the sizes it is available in
+type TemplateImage struct {
+	// relative URL to the full size image
+	URL string
+	// relative URL to a small version of the image
+	Thumbnail string
+	Srcset    template.Srcset
+}
+
+// TemplateAttachment is a document that can be downloaded with a recipe
+type TemplateAttachment struct {
+	// file name of the document
+	Name string
+	// relative URL to the document
+	URL string
+	// number of pages, 0 if they are not known
+	Pages int
+}
+
+// TemplateFragment is a highlighted part of a recipe that matched a search
+type TemplateFragment struct {
+	// name of the recipe section the fragment is from
+	Field string
+	// fragment with matches in <mark> tags
+	HTML template.HTML
 }
 
 // NewTemplate creates a new template instance with all recipe-card related templates parsed
@@ -279,6 +1003,15 @@ func NewTemplate(logger *log.Logger) (tmpl *template.Template, err error) {
 
 	logger.Debugln("Finished parsing index template")
 
+	logger.Debugln("Parsing pagination template")
+	_, err = tmpl.Parse(templatePagination)
+	if err != nil {
+		err = fmt.Errorf("templatePagination: %s", err)
+		return
+	}
+
+	logger.Debugln("Finished parsing pagination template")
+
 	logger.Debugln("Parsing recipes template")
 	_, err = tmpl.Parse(templateRecipes)
 	if err != nil {
@@ -297,6 +1030,60 @@ func NewTemplate(logger *log.Logger) (tmpl *template.Template, err error) {
 
 	logger.Debugln("Finished parsing recipe template")
 
+	logger.Debugln("Parsing pantry template")
+	_, err = tmpl.Parse(templatePantry)
+	if err != nil {
+		err = fmt.Errorf("templatePantry: %s", err)
+		return
+	}
+
+	logger.Debugln("Finished parsing pantry template")
+
+	logger.Debugln("Parsing print template")
+	_, err = tmpl.Parse(templatePrint)
+	if err != nil {
+		err = fmt.Errorf("templatePrint: %s", err)
+		return
+	}
+
+	logger.Debugln("Finished parsing print template")
+
+	logger.Debugln("Parsing import template")
+	_, err = tmpl.Parse(templateImport)
+	if err != nil {
+		err = fmt.Errorf("templateImport: %s", err)
+		return
+	}
+
+	logger.Debugln("Finished parsing import template")
+
+	logger.Debugln("Parsing cookbook template")
+	_, err = tmpl.Parse(templateCookbook)
+	if err != nil {
+		err = fmt.Errorf("templateCookbook: %s", err)
+		return
+	}
+
+	logger.Debugln("Finished parsing cookbook template")
+
+	logger.Debugln("Parsing duplicates template")
+	_, err = tmpl.Parse(templateDuplicates)
+	if err != nil {
+		err = fmt.Errorf("templateDuplicates: %s", err)
+		return
+	}
+
+	logger.Debugln("Finished parsing duplicates template")
+
+	logger.Debugln("Parsing edit template")
+	_, err = tmpl.Parse(templateEdit)
+	if err != nil {
+		err = fmt.Errorf("templateEdit: %s", err)
+		return
+	}
+
+	logger.Debugln("Finished parsing edit template")
+
 	logger.Debugln("Parsing search template")
 	_, err = tmpl.Parse(templateSearch)
 	if err != nil {
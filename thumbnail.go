@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+const (
+	// thumbnailDirName is the folder next to the search index thumbnails are cached in
+	thumbnailDirName = "thumbnails"
+
+	// thumbnailQuality is the JPEG quality of thumbnails
+	thumbnailQuality = 85
+
+	// thumbnailVersion changes when thumbnails are made differently, so old ones are not used
+	thumbnailVersion = "3"
+
+	// thumbnailCacheSize is how many bytes of thumbnails are kept on disk
+	// the least recently used ones are removed past it
+	thumbnailCacheSize = 512 << 20
+
+	// thumbnailTempAge is how old a temporary file must be before pruning removes it,
+	// younger ones may still be being written
+	thumbnailTempAge = time.Hour
+
+	// thumbnailMaxPixels is the largest image resized, decoding takes 4 bytes for every pixel
+	thumbnailMaxPixels = 50 * 1000 * 1000
+)
+
+// thumbnailWidths are the widths images are resized to, requested widths are rounded up to one of them
+var thumbnailWidths = []int{200, 400, 800, 1200}
+
+// thumbnailSlots limits how many images are resized at once, phone photos take a lot of memory
+var thumbnailSlots = make(chan struct{}, runtime.NumCPU())
+
+// thumbnailWidth converts the requested width to a supported width
+func thumbnailWidth(raw string) (int, error) {
+	width, err := strconv.Atoi(raw)
+	if err != nil || width < 1 {
+		return 0, fmt.Errorf("width must be a positive integer")
+	}
+
+	for _, thumbnailWidth := range thumbnailWidths {
+		if width <= thumbnailWidth {
+			return thumbnailWidth, nil
+		}
+	}
+
+	return thumbnailWidths[len(thumbnailWidths)-1], nil
+}
+
+// thumbnailURL is the relative URL of the image resized to width
+func thumbnailURL(imageURL string, width int) string {
+	return imageURL + "?w=" + strconv.Itoa(width)
+}
+
+// thumbnailSrcset lists every size of the image for the browser to choose from
+func thumbnailSrcset(imageURL string) template.Srcset {
+	candidates := make([]string, 0, len(thumbnailWidths))
+	for _, width := range thumbnailWidths {
+		candidates = append(candidates, fmt.Sprintf("%s %dw", thumbnailURL(imageURL, width), width))
+	}
+
+	return template.Srcset(strings.Join(candidates, ", "))
+}
+
+// thumbnailCache resizes images and keeps the results on disk
+type thumbnailCache struct {
+	// folder of cached thumbnails, nothing is cached if it is empty
+	dir string
+
+	lock sync.Mutex
+	// thumbnails being made, keyed by file name, so every request for one waits on the same resize
+	calls map[string]*thumbnailCall
+	// bytes written since the cache was last pruned
+	written int
+}
+
+// thumbnailCall is a thumbnail being made
+type thumbnailCall struct {
+	done      sync.WaitGroup
+	thumbnail []byte
+	err       error
+}
+
+// newThumbnailCache creates a cache in dir, or one that does not keep anything if dir is empty
+func newThumbnailCache(dir string) (*thumbnailCache, error) {
+	if dir != "" {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cache := &thumbnailCache{dir: dir}
+
+	if dir != "" {
+		err := cache.prune(thumbnailCacheSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cache, nil
+}
+
+// get gets the upright JPEG thumbnail of the image at width, or at its own size when width is 0
+// key must change whenever the image does, source is only called when the thumbnail is not cached
+func (c *thumbnailCache) get(key string, width int, source func() ([]byte, error)) ([]byte, error) {
+	sum := sha256.Sum256([]byte(thumbnailVersion + "\x00" + key + "\x00" + strconv.Itoa(width)))
+	name := hex.EncodeToString(sum[:]) + ".jpg"
+	path := ""
+	if c.dir != "" {
+		path = filepath.Join(c.dir, name)
+		if data, err := ioutil.ReadFile(path); err == nil {
+			// pruning removes the least recently used thumbnails first
+			now := time.Now()
+			os.Chtimes(path, now, now)
+			return data, nil
+		}
+	}
+
+	c.lock.Lock()
+	if call, exists := c.calls[name]; exists {
+		c.lock.Unlock()
+		call.done.Wait()
+		return call.thumbnail, call.err
+	}
+
+	if c.calls == nil {
+		c.calls = make(map[string]*thumbnailCall)
+	}
+
+	call := &thumbnailCall{}
+	call.done.Add(1)
+	c.calls[name] = call
+	c.lock.Unlock()
+
+	thumbnailSlots <- struct{}{}
+	call.thumbnail, call.err = c.resize(path, width, source)
+	<-thumbnailSlots
+
+	c.lock.Lock()
+	delete(c.calls, name)
+	c.lock.Unlock()
+	call.done.Done()
+
+	return call.thumbnail, call.err
+}
+
+// resize makes the thumbnail and writes it to path, if there is one
+func (c *thumbnailCache) resize(path string, width int, source func() ([]byte, error)) ([]byte, error) {
+	data, err := source()
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnail, err := resizeToJPEG(data, width)
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		return thumbnail, nil
+	}
+
+	// a failed write only means it is resized again next time
+	if writeFileAtomic(path, thumbnail) != nil {
+		return thumbnail, nil
+	}
+
+	c.lock.Lock()
+	c.written += len(thumbnail)
+	full := c.written > thumbnailCacheSize/8
+	if full {
+		c.written = 0
+	}
+	c.lock.Unlock()
+
+	if full {
+		go c.prune(thumbnailCacheSize)
+	}
+
+	return thumbnail, nil
+}
+
+// prune removes the least recently used thumbnails until the cache fits in size bytes,
+// along with temporary files left behind by failed writes
+func (c *thumbnailCache) prune(size int64) error {
+	files, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	thumbnails := make([]os.FileInfo, 0, len(files))
+	total := int64(0)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		if strings.HasPrefix(file.Name(), ".tmp-") {
+			if time.Since(file.ModTime()) > thumbnailTempAge {
+				os.Remove(filepath.Join(c.dir, file.Name()))
+			}
+
+			continue
+		}
+
+		thumbnails = append(thumbnails, file)
+		total += file.Size()
+	}
+
+	sort.Slice(thumbnails, func(i, j int) bool {
+		return thumbnails[i].ModTime().Before(thumbnails[j].ModTime())
+	})
+
+	for _, thumbnail := range thumbnails {
+		if total <= size {
+			break
+		}
+
+		if os.Remove(filepath.Join(c.dir, thumbnail.Name())) == nil {
+			total -= thumbnail.Size()
+		}
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file that is renamed to path
+func writeFileAtomic(path string, data []byte) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+	}
+
+	return err
+}
+
+// resizeToJPEG turns the image upright, scales it down to width and encodes it as a JPEG
+// images that are already narrow enough, or when width is 0, are only turned
+func resizeToJPEG(data []byte, width int) ([]byte, error) {
+	// check the size from the header before decoding the whole image
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Width*config.Height > thumbnailMaxPixels {
+		return nil, fmt.Errorf("image is %dx%d, larger than %d pixels", config.Width, config.Height, thumbnailMaxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	img = recipe.OrientImage(img, recipe.ImageOrientation(data))
+	if width > 0 {
+		img = resizeImage(img, width)
+	}
+
+	buf := new(bytes.Buffer)
+	err = jpeg.Encode(buf, flattenImage(img), &jpeg.Options{Quality: thumbnailQuality})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// flattenImage draws an image that may be transparent onto white,
+// since JPEG has no transparency and encoding would turn it black
+func flattenImage(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+
+	bounds := img.Bounds()
+	flattened := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flattened, flattened.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flattened, flattened.Bounds(), img, bounds.Min, draw.Over)
+	return flattened
+}
+
+// resizeImage scales img down to width keeping its aspect ratio
+// every destination pixel is the average of the source pixels it covers
+func resizeImage(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= width {
+		return img
+	}
+
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	// RGBA pixels are easy to get at, other images are drawn into one, which draw has a fast path for
+	src, ok := img.(*image.RGBA)
+	if !ok {
+		src = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * src.Rect.Dy() / height
+		y1 := (y + 1) * src.Rect.Dy() / height
+		for x := 0; x < width; x++ {
+			x0 := x * src.Rect.Dx() / width
+			x1 := (x + 1) * src.Rect.Dx() / width
+
+			var r, g, b, a, count int
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(src.Rect.Min.X+x0, src.Rect.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[offset])
+					g += int(src.Pix[offset+1])
+					b += int(src.Pix[offset+2])
+					a += int(src.Pix[offset+3])
+					offset += 4
+					count++
+				}
+			}
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r / count)
+			dst.Pix[offset+1] = uint8(g / count)
+			dst.Pix[offset+2] = uint8(b / count)
+			dst.Pix[offset+3] = uint8(a / count)
+		}
+	}
+
+	return dst
+}
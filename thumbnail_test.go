@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+// exifJPEG adds an EXIF segment with the orientation to a JPEG
+func exifJPEG(t *testing.T, img image.Image, orientation uint16, order binary.ByteOrder) []byte {
+	buf := new(bytes.Buffer)
+	err := jpeg.Encode(buf, img, nil)
+	if err != nil {
+		t.Fatal("Failed to encode image", err)
+	}
+
+	tiff := new(bytes.Buffer)
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+
+	binary.Write(tiff, order, uint16(42))
+	binary.Write(tiff, order, uint32(8))
+	// one IFD entry, the orientation tag as a SHORT with a count of one
+	binary.Write(tiff, order, uint16(1))
+	binary.Write(tiff, order, uint16(0x0112))
+	binary.Write(tiff, order, uint16(3))
+	binary.Write(tiff, order, uint32(1))
+	binary.Write(tiff, order, orientation)
+	binary.Write(tiff, order, uint16(0))
+	binary.Write(tiff, order, uint32(0))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	app1 := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	data := buf.Bytes()
+	output := append([]byte{}, data[:2]...)
+	output = append(output, app1...)
+	output = append(output, segment...)
+
+	return append(output, data[2:]...)
+}
+
+func TestThumbnailWidth(t *testing.T) {
+	tests := map[string]int{
+		"1":     200,
+		"200":   200,
+		"201":   400,
+		"400":   400,
+		"1000":  1200,
+		"99999": 1200,
+	}
+
+	for raw, expected := range tests {
+		width, err := thumbnailWidth(raw)
+		if err != nil {
+			t.Errorf("Failed to get width for \"%s\": %s", raw, err)
+		}
+
+		if width != expected {
+			t.Errorf("thumbnailWidth(\"%s\") != %d: %d", raw, expected, width)
+		}
+	}
+
+	for _, raw := range []string{"", "0", "-5", "wide"} {
+		if _, err := thumbnailWidth(raw); err == nil {
+			t.Errorf("Expected an error for width \"%s\"", raw)
+		}
+	}
+}
+
+func TestResizeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			// alternating columns average to gray
+			if x%2 == 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	resized := resizeImage(img, 10)
+	if resized.Bounds().Dx() != 10 || resized.Bounds().Dy() != 5 {
+		t.Fatalf("Expected a 10x5 image, got %v", resized.Bounds())
+	}
+
+	r, g, b, _ := resized.At(3, 3).RGBA()
+	if r>>8 != 127 || g>>8 != 127 || b>>8 != 127 {
+		t.Errorf("Expected gray, got %d %d %d", r>>8, g>>8, b>>8)
+	}
+
+	if resizeImage(img, 100) != image.Image(img) {
+		t.Error("Images that are narrow enough should not be resized")
+	}
+
+	// RGBA images are read in place, without a copy of every pixel
+	if allocs := testing.AllocsPerRun(10, func() { resizeImage(img, 10) }); allocs > 2 {
+		t.Errorf("Expected only the resized image to be allocated, got %.0f allocations", allocs)
+	}
+
+	// the white right half of a part of the image
+	for y := 0; y < 20; y++ {
+		for x := 20; x < 40; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+
+	resized = resizeImage(img.SubImage(image.Rect(20, 10, 40, 20)), 10)
+	r, g, b, _ = resized.At(3, 3).RGBA()
+	if resized.Bounds().Dx() != 10 || r>>8 != 255 || g>>8 != 255 || b>>8 != 255 {
+		t.Errorf("Expected the white part of the image, got %v %d %d %d", resized.Bounds(), r>>8, g>>8, b>>8)
+	}
+}
+
+func TestThumbnailCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-thumbnails")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	cache, err := newThumbnailCache(filepath.Join(dir, thumbnailDirName))
+	if err != nil {
+		t.Fatal("Failed to create cache", err)
+	}
+
+	buf := new(bytes.Buffer)
+	err = jpeg.Encode(buf, image.NewGray(image.Rect(0, 0, 1000, 500)), nil)
+	if err != nil {
+		t.Fatal("Failed to encode image", err)
+	}
+
+	calls := 0
+	source := func() ([]byte, error) {
+		calls++
+		return buf.Bytes(), nil
+	}
+
+	for i := 0; i < 2; i++ {
+		data, err := cache.get("image", 400, source)
+		if err != nil {
+			t.Fatal("Failed to get thumbnail", err)
+		}
+
+		config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal("Thumbnail is not a JPEG", err)
+		}
+
+		if config.Width != 400 || config.Height != 200 {
+			t.Errorf("Expected a 400x200 thumbnail, got %dx%d", config.Width, config.Height)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected the image to be resized once, got %d", calls)
+	}
+
+	_, err = cache.get("changed image", 400, source)
+	if err != nil {
+		t.Fatal("Failed to get thumbnail", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("Expected a new key to be resized, got %d calls", calls)
+	}
+}
+
+func TestThumbnailCacheConcurrent(t *testing.T) {
+	// nothing is kept on disk, so only collapsing the requests saves a resize
+	cache, err := newThumbnailCache("")
+	if err != nil {
+		t.Fatal("Failed to create cache", err)
+	}
+
+	buf := new(bytes.Buffer)
+	err = jpeg.Encode(buf, image.NewGray(image.Rect(0, 0, 1000, 500)), nil)
+	if err != nil {
+		t.Fatal("Failed to encode image", err)
+	}
+
+	release := make(chan struct{})
+	calls := 0
+	source := func() ([]byte, error) {
+		calls++
+		<-release
+		return buf.Bytes(), nil
+	}
+
+	var started, done sync.WaitGroup
+	sizes := make([]int, 8)
+	for i := range sizes {
+		started.Add(1)
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			data, err := cache.get("image", 200, source)
+			if err == nil {
+				sizes[i] = len(data)
+			}
+		}(i)
+	}
+
+	started.Wait()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected one resize for every request, got %d", calls)
+	}
+
+	for i, size := range sizes {
+		if size == 0 || size != sizes[0] {
+			t.Errorf("Expected request %d to get the thumbnail, got %d bytes", i, size)
+		}
+	}
+}
+
+func TestThumbnailCachePrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-thumbnails")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	cache, err := newThumbnailCache(dir)
+	if err != nil {
+		t.Fatal("Failed to create cache", err)
+	}
+
+	// a.jpg is the least recently used and the old temporary file was left by a failed write
+	now := time.Now()
+	files := map[string]time.Time{
+		"a.jpg":      now.Add(-3 * time.Hour),
+		"b.jpg":      now.Add(-2 * time.Hour),
+		"c.jpg":      now.Add(-time.Hour),
+		".tmp-old":   now.Add(-2 * thumbnailTempAge),
+		".tmp-fresh": now,
+	}
+
+	for name, modified := range files {
+		path := filepath.Join(dir, name)
+		err = ioutil.WriteFile(path, make([]byte, 100), 0644)
+		if err == nil {
+			err = os.Chtimes(path, modified, modified)
+		}
+
+		if err != nil {
+			t.Fatal("Failed to write", name, err)
+		}
+	}
+
+	err = cache.prune(250)
+	if err != nil {
+		t.Fatal("Failed to prune", err)
+	}
+
+	remaining, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal("Failed to read cache", err)
+	}
+
+	names := []string{}
+	for _, file := range remaining {
+		names = append(names, file.Name())
+	}
+
+	sort.Strings(names)
+	if strings.Join(names, ", ") != ".tmp-fresh, b.jpg, c.jpg" {
+		t.Errorf("Expected the oldest thumbnail and temporary file to be removed, got %v", names)
+	}
+}
+
+func TestResizeToJPEGTransparent(t *testing.T) {
+	// a transparent image with an opaque red left half
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.NRGBA{255, 0, 0, 255})
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	err := png.Encode(buf, img)
+	if err != nil {
+		t.Fatal("Failed to encode PNG", err)
+	}
+
+	data, err := resizeToJPEG(buf.Bytes(), 0)
+	if err != nil {
+		t.Fatal("Failed to convert PNG", err)
+	}
+
+	converted, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal("Result is not a JPEG", err)
+	}
+
+	r, g, b, _ := converted.At(13, 8).RGBA()
+	if r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Errorf("Expected transparent pixels to be white, got %d %d %d", r>>8, g>>8, b>>8)
+	}
+
+	r, g, b, _ = converted.At(2, 8).RGBA()
+	if r>>8 < 200 || g>>8 > 80 || b>>8 > 80 {
+		t.Errorf("Expected the opaque half to stay red, got %d %d %d", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestResizeToJPEGPixels(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := png.Encode(buf, image.NewGray(image.Rect(0, 0, 8, 8)))
+	if err != nil {
+		t.Fatal("Failed to encode PNG", err)
+	}
+
+	// claim 10000x10000 pixels in the header, which is refused before decoding
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 10000)
+	binary.BigEndian.PutUint32(data[20:], 10000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err = resizeToJPEG(data, 200)
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("Expected an error for 100 megapixels, got %v", err)
+	}
+}
+
+func TestResizeToJPEGOrientation(t *testing.T) {
+	data := exifJPEG(t, image.NewGray(image.Rect(0, 0, 400, 200)), 6, binary.BigEndian)
+	resized, err := resizeToJPEG(data, 0)
+	if err != nil {
+		t.Fatal("Failed to resize", err)
+	}
+
+	config, err := jpeg.DecodeConfig(bytes.NewReader(resized))
+	if err != nil {
+		t.Fatal("Resized image is not a JPEG", err)
+	}
+
+	if config.Width != 200 || config.Height != 400 {
+		t.Errorf("Expected an upright 200x400 image, got %dx%d", config.Width, config.Height)
+	}
+
+	if recipe.JPEGOrientation(bytes.NewReader(resized)) != 1 {
+		t.Error("Upright images should not have an orientation")
+	}
+}
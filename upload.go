@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+const (
+	// recipeScansSuffix is added to a recipe page URL for uploading scans
+	recipeScansSuffix = "/scans"
+
+	// scanMaxSize is the largest scan accepted, phone photos are usually under 10 MB
+	scanMaxSize = 20 << 20
+
+	// scanMaxUploadSize is the most accepted in one upload of scans
+	scanMaxUploadSize = 5 * scanMaxSize
+
+	// scanMaxPixels is the largest scan accepted by its dimensions, a small file can decode into a huge image
+	scanMaxPixels = 50 * 1000 * 1000
+)
+
+// recipeScansURL is the relative URL scans for the recipe title are uploaded to
+func recipeScansURL(title string) string {
+	return recipePattern + url.PathEscape(title) + recipeScansSuffix
+}
+
+// readScan reads an uploaded scan, making sure it is an image that is not too large
+// the extension it should be saved with is returned with it
+func readScan(header *multipart.FileHeader) ([]byte, string, error) {
+	if header.Size > scanMaxSize {
+		return nil, "", &RecipeEditError{http.StatusRequestEntityTooLarge, "\"" + header.Filename + "\" is larger than 20 MB"}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// trust the bytes rather than the name or the browser
+	contentType := scanContentType(data)
+	if contentType == "" {
+		return nil, "", &RecipeEditError{http.StatusUnsupportedMediaType, "\"" + header.Filename + "\" is not a JPEG, PNG, GIF, WebP or TIFF image"}
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", &RecipeEditError{http.StatusUnsupportedMediaType, "\"" + header.Filename + "\" is not a valid image"}
+	}
+
+	if config.Width*config.Height > scanMaxPixels {
+		return nil, "", &RecipeEditError{http.StatusRequestEntityTooLarge, "\"" + header.Filename + "\" is larger than 50 megapixels"}
+	}
+
+	return data, scanExtensions[contentType], nil
+}
+
+// addScans writes the scans into the folder of the recipe titled id and refreshes its ScanPaths
+func (h *Handler) addScans(id string, headers []*multipart.FileHeader) (*recipe.Recipe, error) {
+	if len(headers) == 0 {
+		return nil, &RecipeEditError{http.StatusBadRequest, "Choose at least one scan to upload"}
+	}
+
+	// check and hash every scan before writing any of them, hashing decodes the whole image so it is done without the lock
+	scans := make([][]byte, 0, len(headers))
+	extensions := make([]string, 0, len(headers))
+	hashes := make(map[int]uint64, len(headers))
+	for i, header := range headers {
+		data, extension, err := readScan(header)
+		if err != nil {
+			return nil, err
+		}
+
+		scans = append(scans, data)
+		extensions = append(extensions, extension)
+		if hash, err := recipe.ImageHash(bytes.NewReader(data)); err == nil {
+			hashes[i] = hash
+		}
+	}
+
+	h.edits.Lock()
+	defer h.edits.Unlock()
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	rec, exists := h.recipes[id]
+	if !exists {
+		return nil, &RecipeEditError{http.StatusNotFound, "There is no recipe named \"" + id + "\""}
+	}
+
+	// readers may be using rec, so change a copy of it
+	updated := *rec
+	updated.ScanPaths = append([]string{}, rec.ScanPaths...)
+	updated.ScanHashes = make(map[string]uint64, len(rec.ScanHashes)+len(scans))
+	for path, hash := range rec.ScanHashes {
+		updated.ScanHashes[path] = hash
+	}
+
+	for i, data := range scans {
+		path, err := rec.AddScan(headers[i].Filename, extensions[i], data)
+		if err != nil {
+			// the upload is all or nothing, so remove the scans already written
+			for _, written := range updated.ScanPaths[len(rec.ScanPaths):] {
+				if removeErr := os.Remove(written); removeErr != nil {
+					h.logger.WithError(removeErr).WithField("path", written).Errorln("Failed to remove scan")
+				}
+			}
+
+			return nil, err
+		}
+
+		h.logger.WithFields(log.Fields{
+			"recipeTitle": id,
+			"path":        path,
+		}).Infoln("Added scan")
+
+		updated.ScanPaths = append(updated.ScanPaths, path)
+		if hash, hashed := hashes[i]; hashed {
+			updated.ScanHashes[path] = hash
+		}
+	}
+
+	sort.Strings(updated.ScanPaths)
+	h.replaceRecipe(rec, &updated)
+
+	return &updated, nil
+}
+
+// UploadScans handles uploading photos of a recipe card to the recipe titled id
+func (h *Handler) UploadScans(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	rec, exists := h.getRecipe(id)
+	if !exists {
+		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, scanMaxUploadSize)
+	err := r.ParseMultipartForm(1 << 20)
+	if err != nil {
+		// only running into the limit of the MaxBytesReader makes the upload too large
+		if strings.Contains(err.Error(), "request body too large") {
+			h.recipePage(w, rec, http.StatusRequestEntityTooLarge, "Unable to read the upload, it must be smaller than 100 MB")
+		} else {
+			h.recipePage(w, rec, http.StatusBadRequest, "Unable to read the upload, it must be a form with scans")
+		}
+
+		return
+	}
+
+	defer r.MultipartForm.RemoveAll()
+
+	rec, err = h.addScans(id, r.MultipartForm.File["scans"])
+	if err == nil {
+		http.Redirect(w, r, recipePattern+url.PathEscape(rec.Title), http.StatusSeeOther)
+		return
+	}
+
+	status := http.StatusInternalServerError
+	message := "Unable to save the scans right now: " + err.Error()
+	if editErr, ok := err.(*RecipeEditError); ok {
+		status = editErr.Status
+		message = editErr.Reason
+	} else {
+		h.logger.WithError(err).WithField("recipeTitle", id).Errorln("Failed to add scans")
+	}
+
+	if current, exists := h.getRecipe(id); exists {
+		rec = current
+	}
+
+	h.recipePage(w, rec, status, message)
+}
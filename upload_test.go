@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tblyler/recipe-card/recipe"
+)
+
+// scanHeaders uploads a PNG for every file name and returns the headers of the upload
+func scanHeaders(t *testing.T, names ...string) []*multipart.FileHeader {
+	buf := new(bytes.Buffer)
+	err := png.Encode(buf, image.NewGray(image.Rect(0, 0, 8, 8)))
+	if err != nil {
+		t.Fatal("Failed to encode PNG", err)
+	}
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for _, name := range names {
+		part, err := writer.CreateFormFile("scans", name)
+		if err != nil {
+			t.Fatal("Failed to create form file", err)
+		}
+
+		part.Write(buf.Bytes())
+	}
+
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	err = request.ParseMultipartForm(1 << 20)
+	if err != nil {
+		t.Fatal("Failed to parse upload", err)
+	}
+
+	return request.MultipartForm.File["scans"]
+}
+
+func TestAddScans(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recipe-card-scans")
+	if err != nil {
+		t.Fatal("Failed to create temp dir", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	handler := newTestHandler(t)
+	handler.recipePath = dir
+	addTestRecipe(t, handler, &recipe.Recipe{
+		Title:    "Toast",
+		Language: "en",
+		DocxPath: filepath.Join(dir, "Toast", "Toast.docx"),
+	})
+
+	err = os.MkdirAll(filepath.Join(dir, "Toast"), 0755)
+	if err != nil {
+		t.Fatal("Failed to create recipe folder", err)
+	}
+
+	rec, err := handler.addScans("Toast", scanHeaders(t, "front.png", "back.png"))
+	if err != nil {
+		t.Fatal("Failed to add scans", err)
+	}
+
+	if len(rec.ScanPaths) != 2 || len(rec.ScanHashes) != 2 {
+		t.Errorf("Expected 2 hashed scans, got %v", rec.ScanPaths)
+	}
+
+	// the name of the last scan is too long to write, so none of them are kept
+	_, err = handler.addScans("Toast", scanHeaders(t, "side.png", strings.Repeat("x", 300)+".png"))
+	if err == nil {
+		t.Fatal("Expected an error writing a scan with a long name")
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "Toast"))
+	if err != nil {
+		t.Fatal("Failed to read recipe folder", err)
+	}
+
+	names := []string{}
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+
+	if strings.Join(names, ", ") != "back.png, front.png" {
+		t.Errorf("Expected only the first upload to be kept, got %v", names)
+	}
+
+	if rec, _ = handler.getRecipe("Toast"); len(rec.ScanPaths) != 2 {
+		t.Errorf("Expected the recipe to keep 2 scans, got %v", rec.ScanPaths)
+	}
+}
+
+// uploadHeaders uploads the data as a scan and returns its header
+func uploadHeaders(t *testing.T, name string, data []byte) []*multipart.FileHeader {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("scans", name)
+	if err != nil {
+		t.Fatal("Failed to create form file", err)
+	}
+
+	part.Write(data)
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	err = request.ParseMultipartForm(1 << 20)
+	if err != nil {
+		t.Fatal("Failed to parse upload", err)
+	}
+
+	return request.MultipartForm.File["scans"]
+}
+
+func TestReadScanPixels(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := png.Encode(buf, image.NewGray(image.Rect(0, 0, 8, 8)))
+	if err != nil {
+		t.Fatal("Failed to encode PNG", err)
+	}
+
+	// claim 10000x10000 pixels in the header, only the header is read to check the size
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 10000)
+	binary.BigEndian.PutUint32(data[20:], 10000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, _, err = readScan(uploadHeaders(t, "huge.png", data)[0])
+	if editErr, ok := err.(*RecipeEditError); !ok || editErr.Status != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for 100 megapixels, got %v", err)
+	}
+}
+
+// zeroReader reads an endless stream of zeros
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+
+	return len(p), nil
+}
+
+func TestUploadScansStatus(t *testing.T) {
+	handler := newTestHandler(t)
+	handler.templates, _ = NewTemplate(nil)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	_, err := writer.CreateFormFile("scans", "huge.png")
+	if err != nil {
+		t.Fatal("Failed to create form file", err)
+	}
+
+	// a scan larger than the whole upload may be, followed by the end of the form
+	end := "\r\n--" + writer.Boundary() + "--\r\n"
+	tooLarge := httptest.NewRequest(http.MethodPost, "/", io.MultiReader(body, io.LimitReader(zeroReader{}, scanMaxUploadSize+1), strings.NewReader(end)))
+	tooLarge.Header.Set("Content-Type", writer.FormDataContentType())
+
+	notForm := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("scans"))
+	notForm.Header.Set("Content-Type", "text/plain")
+
+	truncated := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("--"+writer.Boundary()+"\r\nContent-Disposition: form-data"))
+	truncated.Header.Set("Content-Type", writer.FormDataContentType())
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		status  int
+	}{
+		{"too large", tooLarge, http.StatusRequestEntityTooLarge},
+		{"not a form", notForm, http.StatusBadRequest},
+		{"truncated", truncated, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		handler.UploadScans(recorder, test.request, "Banana Bread")
+		if recorder.Code != test.status {
+			t.Errorf("Expected status %d for %s, got %d", test.status, test.name, recorder.Code)
+		}
+	}
+}